@@ -0,0 +1,480 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/game"
+	"github.com/ytaragin/checkers/pkg/pdn"
+	"github.com/ytaragin/checkers/pkg/players"
+	"github.com/ytaragin/checkers/pkg/tournament"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"play", "play a single game, printing every move", runPlay},
+		{"match", "play a number of games between two players", runMatch},
+		{"analyze", "search a position and report the best move", runAnalyze},
+		{"bench", "measure move generation and search speed", runBench},
+		{"perft", "count the move sequences to a given depth", runPerft},
+		{"tournament", "play a tournament between several players and rate them", runTournament},
+	}
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] <command> [command flags]\n\nCommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-10s %s\n", c.name, c.usage)
+	}
+	fmt.Fprintf(out, "\nRun a command with -h for its flags. Without a command a single match game is played.\n\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func runCommand(args []string) error {
+	if len(args) == 0 {
+		return runMatch(nil)
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(args[1:])
+		}
+	}
+	usage()
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+// gameFlags are the flags that describe the starting position
+type gameFlags struct {
+	variant string
+	fen     string
+}
+
+func registerGameFlags(fs *flag.FlagSet) *gameFlags {
+	gf := &gameFlags{}
+	fs.StringVar(&gf.variant, "variant", board.American.Name, "rules variant: American, International, Brazilian, Russian or Pool")
+	fs.StringVar(&gf.fen, "fen", "", "start from this FEN position")
+	return gf
+}
+
+// timeControlFlag is a time control given on the command line
+type timeControlFlag struct {
+	players.TimeControl
+}
+
+func registerTimeFlag(fs *flag.FlagSet) *timeControlFlag {
+	tc := &timeControlFlag{}
+	fs.Var(tc, "time", "time control per player such as 5m, 5m+3s or 40/90m+30s, untimed if not set")
+	return tc
+}
+
+func (tc *timeControlFlag) String() string {
+	if tc.Base == 0 {
+		return ""
+	}
+	return tc.TimeControl.String()
+}
+
+func (tc *timeControlFlag) Set(value string) error {
+	parsed, err := players.ParseTimeControl(value)
+	if err != nil {
+		return err
+	}
+	tc.TimeControl = parsed
+	return nil
+}
+
+func (gf *gameFlags) newGame() (*game.Game, error) {
+	v, err := board.VariantByName(gf.variant)
+	if err != nil {
+		return nil, err
+	}
+	if gf.fen != "" {
+		return game.InitVariantGameFromFEN(v, gf.fen)
+	}
+	return game.NewVariantGame(v), nil
+}
+
+// newPlayers creates the players of a game, seeding them the way a match
+// seeds the players of each of its games
+func newPlayers(red, blue *playerConfig, seed int64) (players.Player, players.Player, error) {
+	redSeed, blueSeed := players.PlayerSeeds(seed)
+	redPlayer, err := red.newPlayer(board.Red, redSeed)
+	if err != nil {
+		return nil, nil, err
+	}
+	bluePlayer, err := blue.newPlayer(board.Blue, blueSeed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return redPlayer, bluePlayer, nil
+}
+
+func runPlay(args []string) error {
+	fs := flag.NewFlagSet("play", flag.ExitOnError)
+	red := registerPlayerFlags(fs, "red", "human")
+	blue := registerPlayerFlags(fs, "blue", "mcst")
+	gf := registerGameFlags(fs)
+	pdnFile := fs.String("pdn", "", "save the game to this PDN file")
+	printPerStep := fs.Bool("print", true, "print the board after every move")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "seed for the players' random choices")
+	tc := registerTimeFlag(fs)
+	fs.Parse(args)
+
+	g, err := gf.newGame()
+	if err != nil {
+		return err
+	}
+	redPlayer, bluePlayer, err := newPlayers(red, blue, *seed)
+	if err != nil {
+		return err
+	}
+
+	runner := players.RunGame(g, redPlayer, bluePlayer)
+	if tc.Base > 0 {
+		runner.SetTimeControl(tc.TimeControl)
+	}
+	runner.RunTillEnd(*printPerStep)
+
+	if *pdnFile == "" {
+		return nil
+	}
+	pg := pdn.NewGame(g.History(), g.GetState())
+	pg.SetTag("Black", red.String())
+	pg.SetTag("White", blue.String())
+	if g.Variant() != board.American {
+		pg.SetVariant(g.Variant())
+	}
+	if gf.fen != "" {
+		pg.SetTag("SetUp", "1")
+		pg.SetTag("FEN", gf.fen)
+	}
+
+	f, err := os.Create(*pdnFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return pdn.Write(f, pg)
+}
+
+func runMatch(args []string) error {
+	fs := flag.NewFlagSet("match", flag.ExitOnError)
+	red := registerPlayerFlags(fs, "red", "mc")
+	blue := registerPlayerFlags(fs, "blue", "mc")
+	games := fs.Int("games", 1, "number of games to play")
+	workers := fs.Int("workers", 0, "number of games to play at once, 0 for one per CPU")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "seed of the first game")
+	variant := fs.String("variant", board.American.Name, "rules variant: American, International, Brazilian, Russian or Pool")
+	opening := fs.Int("opening", 0, "number of random moves to start every game with")
+	tc := registerTimeFlag(fs)
+	printPerStep := fs.Bool("print", false, "print the board after every move")
+	fs.Parse(args)
+
+	v, err := board.VariantByName(*variant)
+	if err != nil {
+		return err
+	}
+	redFactory, err := red.factory()
+	if err != nil {
+		return err
+	}
+	blueFactory, err := blue.factory()
+	if err != nil {
+		return err
+	}
+	if red.kind == "human" || blue.kind == "human" {
+		*workers = 1
+	}
+
+	fmt.Printf("Red: %s Blue: %s Seed: %d\n", red, blue, *seed)
+	start := time.Now()
+	result := players.RunMultiple(redFactory, blueFactory, players.MatchOptions{
+		Games:         *games,
+		Workers:       *workers,
+		Seed:          *seed,
+		PrintPerStep:  *printPerStep,
+		RandomOpening: *opening,
+		Variant:       v,
+		TimeControl:   tc.TimeControl,
+	})
+	fmt.Printf("Games: %d Moves: %d Time forfeits: %d Time: %s\n",
+		result.Games(), result.Moves, result.TimeForfeits, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+func runAnalyze(args []string) error {
+	fs := flag.NewFlagSet("analyze", flag.ExitOnError)
+	engine := registerPlayerFlags(fs, "engine", "mcst")
+	gf := registerGameFlags(fs)
+	pdnFile := fs.String("pdn", "", "analyze the final position of the first game in this PDN file")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "seed for the engine's random choices")
+	treeFile := fs.String("tree", "", "write the search tree of an mcst engine to this file, as JSON if it ends in .json and as Graphviz DOT otherwise")
+	treeDepth := fs.Int("tree-depth", 3, "levels of the tree to write, 0 for all")
+	treeMinVisits := fs.Int("tree-min-visits", 0, "leave out the tree nodes visited fewer times")
+	fs.Parse(args)
+
+	var g *game.Game
+	var err error
+	if *pdnFile != "" {
+		g, err = readPDNPosition(*pdnFile)
+	} else {
+		g, err = gf.newGame()
+	}
+	if err != nil {
+		return err
+	}
+
+	g.Dump()
+	fmt.Println(g.FEN())
+	if g.GetState() != game.Ongoing {
+		return fmt.Errorf("the game is over")
+	}
+
+	player, err := engine.newPlayer(g.NextTurn(), *seed)
+	if err != nil {
+		return err
+	}
+
+	searcher, ok := player.(players.Searcher)
+	if !ok {
+		start := time.Now()
+		m := player.GetMove(g.Copy())
+		fmt.Printf("Best move: %s Time: %s\n", board.MoveNotation(m), time.Since(start))
+		return nil
+	}
+	if *treeFile == "" {
+		info := searcher.Search(context.Background(), g.Copy(), players.Clock{})
+		info.Print(os.Stdout)
+		return nil
+	}
+
+	treeSearcher, ok := player.(treeSearcher)
+	if !ok {
+		return fmt.Errorf("-tree needs an mcst engine, not %s", engine.kind)
+	}
+	info, roots := treeSearcher.SearchTree(context.Background(), g.Copy(), players.Clock{})
+	info.Print(os.Stdout)
+	if roots == nil {
+		return fmt.Errorf("there is only one legal move, so there is no tree to write")
+	}
+	export := players.TreeExport{MaxDepth: *treeDepth, MinVisits: *treeMinVisits}
+	return writeTree(*treeFile, export, roots)
+}
+
+// treeSearcher is a player whose search trees can be exported
+type treeSearcher interface {
+	SearchTree(ctx context.Context, g *game.Game, clock players.Clock) (players.SearchInfo, []*players.MCSTNode)
+}
+
+func writeTree(filename string, export players.TreeExport, roots []*players.MCSTNode) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(filename, ".json") {
+		err = export.WriteJSON(f, roots...)
+	} else {
+		err = export.WriteDOT(f, roots...)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		fmt.Printf("Wrote the search tree to %s\n", filename)
+	}
+	return err
+}
+
+func readPDNPosition(filename string) (*game.Game, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	games, err := pdn.Read(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(games) == 0 {
+		return nil, fmt.Errorf("no games in %s", filename)
+	}
+	return games[0].Replay()
+}
+
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	engine := registerPlayerFlags(fs, "engine", "mcst")
+	gf := registerGameFlags(fs)
+	playouts := fs.Int("playouts", 1000, "random games to play for the move generation benchmark")
+	positions := fs.Int("positions", 10, "positions to search for the search benchmark")
+	seed := fs.Int64("seed", 1, "seed for the random games")
+	fs.Parse(args)
+
+	start, err := gf.newGame()
+	if err != nil {
+		return err
+	}
+	r := rand.New(rand.NewSource(*seed))
+
+	// Move generation: random games from the start position
+	moveCount := 0
+	began := time.Now()
+	var searchPositions []*game.Game
+	for i := 0; i < *playouts; i++ {
+		g := start.Copy()
+		for g.GetState() == game.Ongoing {
+			moves := g.GetLegalMoves()
+			g.RunMove(moves[r.Intn(len(moves))])
+			moveCount++
+			if len(searchPositions) < *positions && r.Intn(20) == 0 && g.GetState() == game.Ongoing {
+				searchPositions = append(searchPositions, g.Copy())
+			}
+		}
+	}
+	elapsed := time.Since(began)
+	fmt.Printf("Move generation: %d games %d moves in %s (%.0f moves/s)\n",
+		*playouts, moveCount, elapsed, float64(moveCount)/elapsed.Seconds())
+
+	// Search: the engine on positions from the random games
+	if len(searchPositions) == 0 {
+		return nil
+	}
+	began = time.Now()
+	for _, g := range searchPositions {
+		player, err := engine.newPlayer(g.NextTurn(), *seed)
+		if err != nil {
+			return err
+		}
+		player.GetMove(g)
+	}
+	elapsed = time.Since(began)
+	fmt.Printf("Search (%s): %d positions in %s (%s per position)\n",
+		engine, len(searchPositions), elapsed, elapsed/time.Duration(len(searchPositions)))
+	return nil
+}
+
+func runPerft(args []string) error {
+	fs := flag.NewFlagSet("perft", flag.ExitOnError)
+	gf := registerGameFlags(fs)
+	depth := fs.Int("depth", 6, "depth to count to")
+	divide := fs.Bool("divide", false, "show the count below each root move at the final depth")
+	fs.Parse(args)
+
+	g, err := gf.newGame()
+	if err != nil {
+		return err
+	}
+	fmt.Println(g.FEN())
+
+	for d := 1; d <= *depth; d++ {
+		began := time.Now()
+		nodes := game.Perft(g, d)
+		elapsed := time.Since(began)
+		fmt.Printf("Depth: %2d Nodes: %12d Time: %12s (%.0f nodes/s)\n",
+			d, nodes, elapsed, float64(nodes)/elapsed.Seconds())
+	}
+
+	if *divide {
+		var total uint64
+		for _, r := range game.PerftDivide(g, *depth) {
+			fmt.Printf("%-12s %d\n", board.MoveNotation(r.Move), r.Nodes)
+			total += r.Nodes
+		}
+		fmt.Printf("Moves: %d Nodes: %d\n", len(g.GetLegalMoves()), total)
+	}
+	return nil
+}
+
+// playerSpecs collects the players given with a repeated flag
+type playerSpecs []string
+
+func (ps *playerSpecs) String() string {
+	return strings.Join(*ps, " ")
+}
+
+func (ps *playerSpecs) Set(value string) error {
+	*ps = append(*ps, value)
+	return nil
+}
+
+func runTournament(args []string) error {
+	fs := flag.NewFlagSet("tournament", flag.ExitOnError)
+	var specs playerSpecs
+	fs.Var(&specs, "player", "a player as type:key=value,... such as mcst:iterations=2000,c=1.2 (repeat for each player)")
+	games := fs.Int("games", 2, "number of games each pair of players plays")
+	format := fs.String("format", "roundrobin", "pairing format: roundrobin, or gauntlet where the first player plays all the others")
+	variant := fs.String("variant", board.American.Name, "rules variant: American, International, Brazilian, Russian or Pool")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "seed of the first game")
+	opening := fs.Int("opening", 0, "number of random moves to start every game with")
+	tc := registerTimeFlag(fs)
+	quiet := fs.Bool("quiet", false, "do not print a line for every game")
+	fs.Parse(args)
+
+	if len(specs) < 2 {
+		return fmt.Errorf("a tournament needs at least two players")
+	}
+	v, err := board.VariantByName(*variant)
+	if err != nil {
+		return err
+	}
+
+	t := tournament.Tournament{
+		GamesPerPairing: *games,
+		Variant:         v,
+		Seed:            *seed,
+		RandomOpening:   *opening,
+		TimeControl:     tc.TimeControl,
+	}
+	switch strings.ToLower(*format) {
+	case "roundrobin":
+		t.Format = tournament.RoundRobin
+	case "gauntlet":
+		t.Format = tournament.Gauntlet
+	default:
+		return fmt.Errorf("unknown tournament format %q", *format)
+	}
+	if !*quiet {
+		t.Progress = os.Stdout
+	}
+
+	for i, spec := range specs {
+		cfg, err := parsePlayerSpec(spec)
+		if err != nil {
+			return err
+		}
+		if cfg.kind == "human" {
+			return fmt.Errorf("human players cannot take part in a tournament")
+		}
+		factory, err := cfg.factory()
+		if err != nil {
+			return err
+		}
+		t.Entrants = append(t.Entrants, tournament.Entrant{
+			Name: fmt.Sprintf("%d:%s", i+1, spec),
+			New:  factory,
+		})
+	}
+
+	results := t.Run()
+	fmt.Println()
+	results.PrintCrosstable(os.Stdout)
+	fmt.Println()
+	results.PrintRatings(os.Stdout)
+	return nil
+}
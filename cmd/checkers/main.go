@@ -9,13 +9,13 @@ import (
 
 	"github.com/ytaragin/checkers/pkg/board"
 	"github.com/ytaragin/checkers/pkg/game"
-	"github.com/ytaragin/checkers/pkg/players"
 )
 
 var cpuprofile = flag.String("cpuprofile", "", "write cpu profile to file")
 var memprofile = flag.String("memprofile", "", "write memory profile to this file")
 
 func main() {
+	flag.Usage = usage
 	flag.Parse()
 	if *cpuprofile != "" {
 		f, err := os.Create(*cpuprofile)
@@ -23,11 +23,14 @@ func main() {
 			log.Fatal(err)
 		}
 		pprof.StartCPUProfile(f)
-		defer pprof.StopCPUProfile()
 	}
-	runGame()
+
+	err := runCommand(flag.Args())
 	// hardcode()
 
+	if *cpuprofile != "" {
+		pprof.StopCPUProfile()
+	}
 	if *memprofile != "" {
 		f, err := os.Create(*memprofile)
 		if err != nil {
@@ -35,26 +38,12 @@ func main() {
 		}
 		pprof.WriteHeapProfile(f)
 		f.Close()
-		return
 	}
 
-}
-
-func runGame() {
-
-	// redPlayer := players.RandomPlayer{Color: board.Red}
-	redPlayer := players.MCPlayer{Color: board.Red}
-	// bluePlayer := players.RandomPlayer{Color: board.Blue}
-	bluePlayer := players.MCPlayer{Color: board.Blue}
-
-	// g := game.NewGame()
-	// g.Dump()
-	// runner := players.RunGame(g, redPlayer, bluePlayer)
-	// runner.RunTillEnd()
-	// g.Dump()
-
-	players.RunMultiple(redPlayer, bluePlayer, 1)
-
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
 
 func hardcode() {
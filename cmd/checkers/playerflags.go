@@ -0,0 +1,302 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/eval"
+	"github.com/ytaragin/checkers/pkg/players"
+)
+
+var playerTypes = []string{"random", "mc", "rave", "mcst", "minimax", "human"}
+
+// playerConfig holds the command line settings of one player
+type playerConfig struct {
+	kind        string
+	iterations  int
+	duration    time.Duration
+	nodes       int
+	exploration float64
+	depth       int
+	tableSize   int
+	workers     int
+	reuse       bool
+	ponder      bool
+	weights     string
+	playout     string
+	cutoff      int
+	policy      string
+	raveK       float64
+	raveBias    float64
+	verbose     bool
+}
+
+// registerPlayerFlags adds the flags for a player. The flags are named after
+// the prefix, as in -red, -red-iterations and -red-duration.
+func registerPlayerFlags(fs *flag.FlagSet, prefix, defaultKind string) *playerConfig {
+	cfg := &playerConfig{}
+	fs.StringVar(&cfg.kind, prefix, defaultKind, "player type: "+strings.Join(playerTypes, ", "))
+	fs.IntVar(&cfg.iterations, prefix+"-iterations", 0, "search iterations per move (mc, rave, mcst)")
+	fs.DurationVar(&cfg.duration, prefix+"-duration", 0, "search time per move (mc, rave, mcst, minimax)")
+	fs.IntVar(&cfg.nodes, prefix+"-nodes", 0, "positions searched per move (mc, rave, mcst)")
+	fs.Float64Var(&cfg.exploration, prefix+"-c", 0, "exploration constant, which makes mc share its playouts by UCB1 (mc, rave, mcst)")
+	fs.IntVar(&cfg.depth, prefix+"-depth", 0, "search depth (minimax)")
+	fs.IntVar(&cfg.tableSize, prefix+"-table", 0, "transposition table entries (rave, mcst, minimax)")
+	fs.IntVar(&cfg.workers, prefix+"-workers", 0, "parallel searches (mcst)")
+	fs.BoolVar(&cfg.reuse, prefix+"-reuse", false, "keep the search tree between moves (mcst)")
+	fs.BoolVar(&cfg.ponder, prefix+"-ponder", false, "search on the opponent's time, keeping the tree between moves (mcst)")
+	fs.StringVar(&cfg.weights, prefix+"-eval", "", "evaluation weights such as king=1.6,mobility=0.05 or material (minimax, mcst)")
+	fs.StringVar(&cfg.playout, prefix+"-playout", "random", "playout policy: random or heavy (mcst, rave)")
+	fs.IntVar(&cfg.cutoff, prefix+"-playout-depth", 0, "moves after which a playout is scored by evaluation, 0 to play to the end (mcst)")
+	fs.StringVar(&cfg.policy, prefix+"-policy", "ucb1", "tree policy: "+strings.Join(treePolicies, ", ")+" (mcst)")
+	fs.Float64Var(&cfg.raveK, prefix+"-rave-k", 0, "visits at which the AMAF value gets half the weight (rave)")
+	fs.Float64Var(&cfg.raveBias, prefix+"-rave-bias", 0, "use the minimum MSE schedule with this AMAF bias (rave)")
+	fs.BoolVar(&cfg.verbose, prefix+"-verbose", false, "print search details")
+	return cfg
+}
+
+func (cfg *playerConfig) String() string {
+	var sb strings.Builder
+	sb.WriteString(cfg.kind)
+	if cfg.iterations > 0 {
+		fmt.Fprintf(&sb, " iterations=%d", cfg.iterations)
+	}
+	if cfg.duration > 0 {
+		fmt.Fprintf(&sb, " duration=%s", cfg.duration)
+	}
+	if cfg.nodes > 0 {
+		fmt.Fprintf(&sb, " nodes=%d", cfg.nodes)
+	}
+	if cfg.exploration > 0 {
+		fmt.Fprintf(&sb, " c=%.3f", cfg.exploration)
+	}
+	if cfg.depth > 0 {
+		fmt.Fprintf(&sb, " depth=%d", cfg.depth)
+	}
+	if cfg.workers > 0 {
+		fmt.Fprintf(&sb, " workers=%d", cfg.workers)
+	}
+	if cfg.reuse {
+		sb.WriteString(" reuse")
+	}
+	if cfg.ponder {
+		sb.WriteString(" ponder")
+	}
+	if cfg.weights != "" {
+		fmt.Fprintf(&sb, " eval=%s", cfg.weights)
+	}
+	if cfg.playout != "" && cfg.playout != "random" {
+		fmt.Fprintf(&sb, " playout=%s", cfg.playout)
+	}
+	if cfg.cutoff > 0 {
+		fmt.Fprintf(&sb, " playout-depth=%d", cfg.cutoff)
+	}
+	if cfg.policy != "" && cfg.policy != "ucb1" {
+		fmt.Fprintf(&sb, " policy=%s", cfg.policy)
+	}
+	if cfg.raveK > 0 {
+		fmt.Fprintf(&sb, " rave-k=%g", cfg.raveK)
+	}
+	if cfg.raveBias > 0 {
+		fmt.Fprintf(&sb, " rave-bias=%g", cfg.raveBias)
+	}
+	return sb.String()
+}
+
+// newPlayer creates the configured player for the color. The seed is used by
+// the players that make random choices.
+func (cfg *playerConfig) newPlayer(color board.PieceColor, seed int64) (players.Player, error) {
+	switch cfg.kind {
+	case "random":
+		return players.RandomPlayer{Color: color, Rand: players.NewRand(seed)}, nil
+	case "mc":
+		return players.MCPlayer{
+			Color:        color,
+			Verbose:      cfg.verbose,
+			SearchBudget: cfg.budget(),
+			Rand:         players.NewRand(seed),
+		}, nil
+	case "rave":
+		playout, err := cfg.playoutPolicy()
+		if err != nil {
+			return nil, err
+		}
+		var schedule players.RaveSchedule
+		switch {
+		case cfg.raveBias > 0:
+			schedule = players.MinimumMSESchedule{Bias: cfg.raveBias}
+		case cfg.raveK > 0:
+			schedule = players.EquivalenceSchedule{K: cfg.raveK}
+		}
+		return players.MCPlayerRave{
+			Color:        color,
+			Verbose:      cfg.verbose,
+			TableSize:    cfg.tableSize,
+			SearchBudget: cfg.budget(),
+			Schedule:     schedule,
+			Playout:      playout,
+			Rand:         players.NewRand(seed),
+		}, nil
+	case "mcst":
+		weights, err := eval.ParseWeights(cfg.weights)
+		if err != nil {
+			return nil, err
+		}
+		playout, err := cfg.playoutPolicy()
+		if err != nil {
+			return nil, err
+		}
+		policy, err := cfg.treePolicy()
+		if err != nil {
+			return nil, err
+		}
+		mc := players.MCSTPlayer{
+			Color:              color,
+			SelectionAlgorithm: players.MostVisits,
+			SearchBudget:       cfg.budget(),
+			Verbose:            cfg.verbose,
+			TableSize:          cfg.tableSize,
+			Rand:               players.NewRand(seed),
+			Workers:            cfg.workers,
+			Playout:            playout,
+			PlayoutDepth:       cfg.cutoff,
+			Weights:            &weights,
+			TreePolicy:         policy,
+		}
+		if cfg.reuse || cfg.ponder {
+			p := players.NewPersistentMCSTPlayer(mc)
+			p.Ponder = cfg.ponder
+			return p, nil
+		}
+		return mc, nil
+	case "minimax":
+		weights, err := eval.ParseWeights(cfg.weights)
+		if err != nil {
+			return nil, err
+		}
+		return players.MinimaxPlayer{
+			Color:     color,
+			MaxDepth:  cfg.depth,
+			Duration:  cfg.duration,
+			Verbose:   cfg.verbose,
+			TableSize: cfg.tableSize,
+			Weights:   &weights,
+		}, nil
+	case "human":
+		return players.NewHumanPlayer(color, nil, nil), nil
+	}
+	return nil, fmt.Errorf("unknown player type %q, expected one of %s", cfg.kind, strings.Join(playerTypes, ", "))
+}
+
+// parsePlayerSpec reads a player written as "type:key=value,...", such as
+// "mcst:iterations=2000,c=1.2" or "minimax:depth=6,eval=king=1.6;tempo=0".
+// The keys match the player flag suffixes.
+func parsePlayerSpec(spec string) (*playerConfig, error) {
+	kind, options, _ := strings.Cut(spec, ":")
+	cfg := &playerConfig{kind: kind}
+	if options == "" {
+		return cfg, nil
+	}
+
+	for _, opt := range strings.Split(options, ",") {
+		key, value, ok := strings.Cut(opt, "=")
+		if !ok && key != "verbose" && key != "reuse" && key != "ponder" {
+			return nil, fmt.Errorf("invalid option %q in player %q", opt, spec)
+		}
+		var err error
+		switch key {
+		case "iterations":
+			cfg.iterations, err = strconv.Atoi(value)
+		case "duration":
+			cfg.duration, err = time.ParseDuration(value)
+		case "nodes":
+			cfg.nodes, err = strconv.Atoi(value)
+		case "c":
+			cfg.exploration, err = strconv.ParseFloat(value, 64)
+		case "depth":
+			cfg.depth, err = strconv.Atoi(value)
+		case "table":
+			cfg.tableSize, err = strconv.Atoi(value)
+		case "workers":
+			cfg.workers, err = strconv.Atoi(value)
+		case "playout":
+			cfg.playout = value
+		case "playout-depth":
+			cfg.cutoff, err = strconv.Atoi(value)
+		case "policy":
+			cfg.policy = value
+		case "rave-k":
+			cfg.raveK, err = strconv.ParseFloat(value, 64)
+		case "rave-bias":
+			cfg.raveBias, err = strconv.ParseFloat(value, 64)
+		case "eval":
+			// Weights are separated by semicolons here, as commas separate
+			// the options
+			cfg.weights = value
+		case "reuse":
+			cfg.reuse = value == "" || value == "true"
+		case "ponder":
+			cfg.ponder = value == "" || value == "true"
+		case "verbose":
+			cfg.verbose = value == "" || value == "true"
+		default:
+			return nil, fmt.Errorf("unknown option %q in player %q", key, spec)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid option %q in player %q: %v", opt, spec, err)
+		}
+	}
+	return cfg, nil
+}
+
+// budget returns the search budget of the Monte Carlo players
+func (cfg *playerConfig) budget() players.SearchBudget {
+	return players.SearchBudget{
+		Iterations:        cfg.iterations,
+		Duration:          cfg.duration,
+		MaxNodes:          cfg.nodes,
+		ExplorationWeight: cfg.exploration,
+	}
+}
+
+func (cfg *playerConfig) playoutPolicy() (players.PlayoutPolicy, error) {
+	switch cfg.playout {
+	case "", "random":
+		return players.RandomPlayout{}, nil
+	case "heavy":
+		return players.DefaultHeavyPlayout, nil
+	}
+	return nil, fmt.Errorf("unknown playout policy %q, expected random or heavy", cfg.playout)
+}
+
+var treePolicies = []string{"ucb1", "ucb1-tuned", "puct", "bias"}
+
+func (cfg *playerConfig) treePolicy() (players.TreePolicy, error) {
+	switch cfg.policy {
+	case "", "ucb1":
+		return players.UCB1, nil
+	case "ucb1-tuned":
+		return players.UCB1Tuned, nil
+	case "puct":
+		return players.PUCT, nil
+	case "bias":
+		return players.ProgressiveBias, nil
+	}
+	return nil, fmt.Errorf("unknown tree policy %q, expected one of %s", cfg.policy, strings.Join(treePolicies, ", "))
+}
+
+// factory returns a factory making the configured player. The configuration
+// is checked once here so the factory itself cannot fail.
+func (cfg *playerConfig) factory() (players.PlayerFactory, error) {
+	if _, err := cfg.newPlayer(board.Red, 0); err != nil {
+		return nil, err
+	}
+	return func(color board.PieceColor, seed int64) players.Player {
+		p, _ := cfg.newPlayer(color, seed)
+		return p
+	}, nil
+}
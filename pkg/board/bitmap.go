@@ -1,40 +1,39 @@
 package board
 
-func boardToBitmap(board Board, rows, cols int) (uint64, uint64) {
-	var red uint64
-	var blue uint64
-	for r := 0; r < rows; r++ {
-		for c := 0; c < cols; c++ {
-			pos := NewPosition(r, c)
-			if board.GetPiece(pos).Color == Red {
-				red |= 1 << (r*cols + c)
-			} else if board.GetPiece(pos).Color == Blue {
-				blue |= 1 << (r*cols + c)
-			}
-		}
-	}
-	return red, blue
-}
+import "math/bits"
 
 func getMaskForPosition(row, col int) uint64 {
-	return (1 << (row*BoardCols + col))
+	return getMaskForBoard(row, col, BoardCols)
+}
+
+// getMaskForBoard gives a bit to each playable square only, so boards of up
+// to 10x10 fit in a single uint64
+func getMaskForBoard(row, col, cols int) uint64 {
+	return 1 << getSpotForBoard(row, col, cols)
+}
 
+func getSpotForBoard(row, col, cols int) int {
+	return (row*cols + col) / 2
 }
 
 func getMaskSpot(row, col int) int {
-	return (row*BoardCols + col)
+	return getSpotForBoard(row, col, BoardCols)
+}
+
+func getSpotForMask(mask uint64) int {
+	return bits.TrailingZeros64(mask)
 }
 
 func isBitSet(bitmap uint64, row, col int) bool {
-	return (bitmap & (1 << (row*BoardCols + col))) != 0
+	return (bitmap & getMaskForPosition(row, col)) != 0
 }
 
 func setBit(bitmap *uint64, row, col int) {
-	*bitmap = *bitmap | (1 << (row*BoardCols + col))
+	*bitmap = *bitmap | getMaskForPosition(row, col)
 }
 
 func clearBit(bitmap *uint64, row, col int) {
-	*bitmap = *bitmap & ^(1 << (row*BoardCols + col))
+	*bitmap = *bitmap & ^getMaskForPosition(row, col)
 }
 
 func isBitSetByMask(bitmap uint64, mask uint64) bool {
@@ -60,13 +59,3 @@ func setBitByPos(bitmap *uint64, pos *Position) {
 func clearBitByPos(bitmap *uint64, pos *Position) {
 	*bitmap = *bitmap & ^pos.mask
 }
-func bitmapToBoard(bitmap uint64, rows, cols int) [][]bool {
-	board := make([][]bool, rows)
-	for r := 0; r < rows; r++ {
-		board[r] = make([]bool, cols)
-		for c := 0; c < cols; c++ {
-			board[r][c] = isBitSet(bitmap, r, c)
-		}
-	}
-	return board
-}
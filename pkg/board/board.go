@@ -2,6 +2,9 @@ package board
 
 import (
 	"fmt"
+	"io"
+	"math/bits"
+	"os"
 )
 
 const BoardRows int = 8
@@ -9,25 +12,13 @@ const BoardCols int = 8
 const RedDirection int = 1
 const BlueDirection int = -1
 
+// AllPositionList and AllMovesList are the tables of the American variant
 var AllPositionList []*Position
-var AllMovesList [64]Moves
+var AllMovesList []Moves
 
 func init() {
-
-	b := NewEmptyBoard()
-	AllPositionList = make([]*Position, 0, 32)
-
-	// Initialize spots
-	for row := 0; row < BoardRows; row++ {
-		for col := 0; col < BoardCols; col++ {
-			if (row+col)%2 != 0 {
-				AllMovesList[getMaskSpot(row, col)] = b.createMoves(row, col)
-				AllPositionList = append(AllPositionList, NewPosition(row, col))
-			} else {
-				setBitByMask(&b.Invalid, getMaskForPosition(row, col))
-			}
-		}
-	}
+	AllPositionList = American.positions
+	AllMovesList = American.moves
 }
 
 // Board represents the checkers board
@@ -37,13 +28,20 @@ type Board struct {
 	BlueMask uint64
 	Kings    uint64
 	Invalid  uint64
+	hash     uint64
+	variant  *Variant
 	// AllMoves     [64]Moves
 	// AllPositions []*Position
 }
 
 // NewBoard creates and initializes a new checkers board
 func NewBoard() *Board {
-	board := &Board{}
+	return NewVariantBoard(American)
+}
+
+// NewVariantBoard creates a board set up for the variant
+func NewVariantBoard(v *Variant) *Board {
+	board := NewEmptyVariantBoard(v)
 	board.initializeBoard()
 	return board
 }
@@ -52,36 +50,25 @@ func NewEmptyBoard() *Board {
 	return &Board{}
 }
 
-func (b *Board) createMoves(row, col int) Moves {
-	// Create a new Moves instance
-	moves := Moves{
-		Moves:     make(map[PieceColor][]PlainMove),
-		Jumps:     make(map[PieceColor][]JumpMove),
-		KingMoves: []PlainMove{},
-		KingJumps: []JumpMove{},
-	}
-
-	redJumps := createJumps(row, col, RedDirection, []JumpMove{})
-	blueJumps := createJumps(row, col, BlueDirection, []JumpMove{})
-	moves.Jumps[Red] = redJumps
-	moves.Jumps[Blue] = blueJumps
-
-	redMoves := createMovesInRow(row, col, RedDirection, []PlainMove{})
-	blueMoves := createMovesInRow(row, col, BlueDirection, []PlainMove{})
-	moves.Moves[Red] = redMoves
-	moves.Moves[Blue] = blueMoves
-
-	moves.KingMoves = createKingMoves(row, col, moves.KingMoves)
-	moves.KingJumps = createKingJumps(row, col, moves.KingJumps)
+// NewEmptyVariantBoard creates an empty board for the variant
+func NewEmptyVariantBoard(v *Variant) *Board {
+	return &Board{variant: v}
+}
 
-	return moves
+// Variant returns the rules the board is played by
+func (b *Board) Variant() *Variant {
+	if b.variant == nil {
+		return American
+	}
+	return b.variant
 }
 
 func (b *Board) GetAllLegalMovesForColor(color PieceColor) MoveList {
 	moves := make(MoveList, 0, 3)
 	onlyJumps := false
+	v := b.Variant()
 
-	for _, pos := range AllPositionList {
+	for _, pos := range v.positions {
 		if b.isSpotEmpty(pos) {
 			continue
 		}
@@ -98,9 +85,52 @@ func (b *Board) GetAllLegalMovesForColor(color PieceColor) MoveList {
 			}
 		}
 	}
+
+	if onlyJumps && v.FlyingKings {
+		moves = uniqueCaptures(moves)
+	}
+	if onlyJumps && v.MaxCapture {
+		moves = longestCaptures(moves)
+	}
 	return moves
 }
 
+// uniqueCaptures drops captures that only differ from another one in the
+// squares a flying king passed over, since they lead to the same position
+func uniqueCaptures(moves MoveList) MoveList {
+	type captureKey struct {
+		start, end, jumped uint64
+	}
+	seen := make(map[captureKey]bool, len(moves))
+	unique := moves[:0:0]
+	for _, m := range moves {
+		key := captureKey{start: m.GetStart().mask, end: m.GetEnd().mask}
+		for _, pos := range m.GetJumpedPositions() {
+			key.jumped |= pos.mask
+		}
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, m)
+		}
+	}
+	return unique
+}
+
+// longestCaptures keeps only the captures that take the most pieces
+func longestCaptures(moves MoveList) MoveList {
+	most := 0
+	for _, m := range moves {
+		most = max(most, len(m.GetJumpedPositions()))
+	}
+	longest := moves[:0:0]
+	for _, m := range moves {
+		if len(m.GetJumpedPositions()) == most {
+			longest = append(longest, m)
+		}
+	}
+	return longest
+}
+
 func (b *Board) getColorMask(color PieceColor) *uint64 {
 	if color == Blue {
 		return &b.BlueMask
@@ -116,7 +146,7 @@ func (b *Board) GetMovesForPosition(pos *Position, color PieceColor, onlyJumps b
 	// 	return MoveList{}, false
 	// }
 
-	mvs := &AllMovesList[getMaskSpot(pos.Row, pos.Col)]
+	mvs := &b.Variant().moves[getSpotForMask(pos.mask)]
 	// fmt.Printf("Moves are: %+v\n", mvs)
 
 	return b.getValidMoves(p, mvs, onlyJumps)
@@ -160,10 +190,19 @@ func (b *Board) getJumpMoves(piece *Piece, moves *Moves) MoveList {
 	}
 
 	ret_jumps := MoveList{}
+	v := b.Variant()
 
 	for _, jump := range jumps {
 		b2 := *b
 		jump.DoMove(&b2)
+		// Captured pieces stay in the way until the capture is over and
+		// cannot be jumped twice
+		for _, pos := range jump.GetJumpedPositions() {
+			setBitByPos(&b2.Invalid, pos)
+		}
+		if v.PromoteDuringCapture && !piece.IsKing && v.IsPromotionRow(jump.GetEnd().Row, piece.Color) {
+			b2.KingMe(jump.GetEnd())
+		}
 		jumps2, _ := b2.GetMovesForPosition(jump.GetEnd(), piece.Color, true)
 		if len(jumps2) > 0 {
 			for _, j2 := range jumps2 {
@@ -210,18 +249,51 @@ func (b *Board) GetPiece(pos *Position) *Piece {
 	return b.getPieceByMask(pos.mask)
 }
 
+// CountPieces returns the number of men and kings of the given color
+func (b *Board) CountPieces(color PieceColor) (int, int) {
+	mask := *b.getColorMask(color)
+	kings := bits.OnesCount64(mask & b.Kings)
+	return bits.OnesCount64(mask) - kings, kings
+}
+
+// Promote crowns the piece that made the move if it reached the far row. It
+// returns true if a man was crowned.
+func (b *Board) Promote(m Move, color PieceColor) bool {
+	v := b.Variant()
+	end := m.GetEnd()
+	if v.IsPromotionRow(end.Row, color) {
+		return b.KingMe(end)
+	}
+	if v.PromoteDuringCapture {
+		for _, pos := range moveLandings(m) {
+			if v.IsPromotionRow(pos.Row, color) {
+				return b.KingMe(end)
+			}
+		}
+	}
+	return false
+}
+
 func (b *Board) KingMe(pos *Position) bool {
+	p := b.GetPiece(pos)
+	if p == nil || p.IsKing {
+		return false
+	}
 	setBitByPos(&b.Kings, pos)
+	b.hash ^= zobristKey(p, pos.mask) ^ zobristKey(b.GetPiece(pos), pos.mask)
 	return true
 }
 
+// SetPiece places the piece on the position, replacing any piece already there
 func (b *Board) SetPiece(pos *Position, piece *Piece) bool {
-	mask := b.getColorMask(piece.Color)
+	b.removePiece(pos)
 
+	mask := b.getColorMask(piece.Color)
 	setBitByPos(mask, pos)
 	if piece.IsKing {
 		setBitByPos(&b.Kings, pos)
 	}
+	b.hash ^= zobristKey(piece, pos.mask)
 
 	return true
 }
@@ -262,7 +334,10 @@ func (b *Board) removePiece(pos *Position) *Piece {
 		clearBitByPos(&b.BlueMask, pos)
 	}
 
-	clearBitByPos(&b.Kings, pos)
+	if p != nil {
+		clearBitByPos(&b.Kings, pos)
+		b.hash ^= zobristKey(p, pos.mask)
+	}
 	return p
 }
 
@@ -281,18 +356,19 @@ func (b *Board) isSpotEmpty(pos *Position) bool {
 	// posSetred := isBitSetByPos(b.Red, pos)
 	// posSetblue := isBitSetByPos(b.Blue, pos)
 	// return posSetred && posSetblue
-	return !isBitSetByPos(b.RedMask, pos) && !isBitSetByPos(b.BlueMask, pos)
+	return !isBitSetByPos(b.RedMask, pos) && !isBitSetByPos(b.BlueMask, pos) && !isBitSetByPos(b.Invalid, pos)
+}
+
+// isPathClear returns true if none of the squares in the mask are taken
+func (b *Board) isPathClear(path uint64) bool {
+	return (b.RedMask|b.BlueMask|b.Invalid)&path == 0
 }
 
 func (b *Board) addPieces(row_start, row_end int, piece *Piece) {
 
-	for row := row_start; row <= row_end; row++ {
-		j_start := 0
-		if row%2 == 0 {
-			j_start = 1
-		}
-		for col := j_start; col < BoardCols; col += 2 {
-			setBit(b.getColorMask(piece.Color), row, col)
+	for _, pos := range b.Variant().positions {
+		if pos.Row >= row_start && pos.Row <= row_end {
+			setBitByPos(b.getColorMask(piece.Color), pos)
 		}
 	}
 
@@ -300,59 +376,51 @@ func (b *Board) addPieces(row_start, row_end int, piece *Piece) {
 
 // initializeBoard initializes the checkers board with the proper checkers setup
 func (b *Board) initializeBoard() {
+	v := b.Variant()
 
-	// AllPositionList = make([]*Position, 0, 32)
-	//
-	// // Initialize spots
-	// for row := 0; row < BoardRows; row++ {
-	// 	for col := 0; col < BoardCols; col++ {
-	// 		if (row+col)%2 != 0 {
-	// 			AllMovesList[getMaskSpot(row, col)] = b.createMoves(row, col)
-	// 			AllPositionList = append(AllPositionList, NewPosition(row, col))
-	// 		} else {
-	// 			setBitByMask(&b.Invalid, getMaskForPosition(row, col))
-	// 		}
-	// 	}
-	// }
-
-	// mvs := &b.Grid[5][2]
-	// fmt.Printf("Moves are: %+v\n", mvs)
-
-	b.addPieces(0, 2, RedNormalPiece)
-	b.addPieces(BoardRows-3, BoardRows-1, BlueNormalPiece)
+	b.addPieces(0, v.PieceRows-1, RedNormalPiece)
+	b.addPieces(v.Rows-v.PieceRows, v.Rows-1, BlueNormalPiece)
+	b.Rehash()
 }
 
 func (b *Board) Dump(start *Position, last *Position) {
+	b.DumpTo(os.Stdout, start, last)
+}
+
+// DumpTo writes the board to w, marking the start and end of the last move
+func (b *Board) DumpTo(w io.Writer, start *Position, last *Position) {
+	v := b.Variant()
 
-	fmt.Printf("  ")
-	for i := 0; i < BoardRows; i++ {
-		fmt.Printf("%d  ", i)
+	fmt.Fprintf(w, "  ")
+	for i := 0; i < v.Cols; i++ {
+		fmt.Fprintf(w, "%d  ", i)
 	}
-	fmt.Println()
-	for i := 0; i < BoardRows; i++ {
-		fmt.Printf("%d ", i)
-		for j := 0; j < BoardCols; j++ {
-			mask := getMaskForPosition(i, j)
-			p := b.getPieceByMask(mask)
-			if isBitSetByMask(b.Invalid, mask) {
-				fmt.Print("   ")
-			} else if p == nil {
-				fmt.Print("- ")
-				printMark(i, j, start)
+	fmt.Fprintln(w)
+	for i := 0; i < v.Rows; i++ {
+		fmt.Fprintf(w, "%d ", i)
+		for j := 0; j < v.Cols; j++ {
+			if !v.IsPlayable(i, j) {
+				fmt.Fprint(w, "-  ")
+				continue
+			}
+			p := b.getPieceByMask(getMaskForBoard(i, j, v.Cols))
+			if p == nil {
+				fmt.Fprint(w, "- ")
+				printMark(w, i, j, start)
 			} else {
-				p.Dump() // Call Piece.Dump() method
-				printMark(i, j, last)
+				p.DumpTo(w)
+				printMark(w, i, j, last)
 			}
 		}
-		fmt.Println()
+		fmt.Fprintln(w)
 
 	}
 }
 
-func printMark(row, col int, pos *Position) {
+func printMark(w io.Writer, row, col int, pos *Position) {
 	if pos != nil && pos.Row == row && pos.Col == col {
-		fmt.Print("<")
+		fmt.Fprint(w, "<")
 	} else {
-		fmt.Print(" ")
+		fmt.Fprint(w, " ")
 	}
 }
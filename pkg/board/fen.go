@@ -0,0 +1,132 @@
+package board
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FEN color letters. Red starts on squares 1-12 and moves first, so it is
+// Black in FEN and Blue is White.
+const (
+	fenRed  = "B"
+	fenBlue = "W"
+)
+
+func fenColor(color PieceColor) string {
+	if color == Red {
+		return fenRed
+	}
+	return fenBlue
+}
+
+func parseFENColor(s string) (PieceColor, error) {
+	switch strings.ToUpper(s) {
+	case fenRed:
+		return Red, nil
+	case fenBlue:
+		return Blue, nil
+	}
+	return Red, fmt.Errorf("invalid color %q", s)
+}
+
+// ParseFEN parses a position such as "W:W21,22,K30:B1,2,K9" and returns the
+// board and the side to move. Square ranges like "B1-12" are accepted too.
+func ParseFEN(fen string) (*Board, PieceColor, error) {
+	return American.ParseFEN(fen)
+}
+
+// ParseFEN parses a position on a board of this variant
+func (v *Variant) ParseFEN(fen string) (*Board, PieceColor, error) {
+	fen = strings.TrimSuffix(strings.TrimSpace(fen), ".")
+	fields := strings.Split(fen, ":")
+	if fields[0] == "" {
+		return nil, Red, fmt.Errorf("empty FEN")
+	}
+
+	turn, err := parseFENColor(fields[0])
+	if err != nil {
+		return nil, Red, fmt.Errorf("FEN %q: %w", fen, err)
+	}
+
+	b := NewEmptyVariantBoard(v)
+	for _, field := range fields[1:] {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		color, err := parseFENColor(field[:1])
+		if err != nil {
+			return nil, Red, fmt.Errorf("FEN %q: %w", fen, err)
+		}
+		if err := b.parseFENPieces(color, field[1:]); err != nil {
+			return nil, Red, fmt.Errorf("FEN %q: %w", fen, err)
+		}
+	}
+
+	return b, turn, nil
+}
+
+func (b *Board) parseFENPieces(color PieceColor, list string) error {
+	if list == "" {
+		return nil
+	}
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		king := strings.HasPrefix(strings.ToUpper(item), "K")
+		if king {
+			item = item[1:]
+		}
+
+		first, last := item, item
+		if i := strings.Index(item, "-"); i >= 0 {
+			first, last = item[:i], item[i+1:]
+		}
+		from, err := strconv.Atoi(first)
+		if err != nil {
+			return fmt.Errorf("invalid square %q", item)
+		}
+		to, err := strconv.Atoi(last)
+		if err != nil {
+			return fmt.Errorf("invalid square %q", item)
+		}
+
+		for sq := from; sq <= to; sq++ {
+			pos, err := b.Variant().PositionFromSquare(sq)
+			if err != nil {
+				return err
+			}
+			if !b.isSpotEmpty(pos) {
+				return fmt.Errorf("square %d used twice", sq)
+			}
+			b.SetPiece(pos, &Piece{Color: color, IsKing: king})
+		}
+	}
+	return nil
+}
+
+// FEN returns the position in FEN with the given side to move
+func (b *Board) FEN(turn PieceColor) string {
+	return fmt.Sprintf("%s:%s%s:%s%s",
+		fenColor(turn),
+		fenBlue, b.fenPieces(Blue),
+		fenRed, b.fenPieces(Red))
+}
+
+// fenPieces lists the squares of the color in ascending order, which is the
+// order of the variant's positions
+func (b *Board) fenPieces(color PieceColor) string {
+	var parts []string
+	for _, pos := range b.Variant().positions {
+		p := b.GetPiece(pos)
+		if p == nil || p.Color != color {
+			continue
+		}
+		if p.IsKing {
+			parts = append(parts, "K"+strconv.Itoa(pos.Square()))
+		} else {
+			parts = append(parts, strconv.Itoa(pos.Square()))
+		}
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,51 @@
+package board
+
+import "testing"
+
+func TestFENRoundTrip(t *testing.T) {
+	fens := []string{
+		"W:W21,22,K30:B1,2,K9",
+		"B:W21,22,23,24,25,26,27,28,29,30,31,32:B1,2,3,4,5,6,7,8,9,10,11,12",
+		"W:W:BK32",
+	}
+	for _, fen := range fens {
+		b, turn, err := ParseFEN(fen)
+		if err != nil {
+			t.Fatalf("%s: %v", fen, err)
+		}
+		if got := b.FEN(turn); got != fen {
+			t.Errorf("Round trip of %s gave %s", fen, got)
+		}
+		if b.Hash() != b.ComputeHash() {
+			t.Errorf("%s: hash not set", fen)
+		}
+	}
+}
+
+func TestFENStartPosition(t *testing.T) {
+	b, turn, err := ParseFEN("B:W21-32:B1-12.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if turn != Red {
+		t.Errorf("Expected Red to move")
+	}
+	if *b != *NewBoard() {
+		t.Errorf("Range FEN does not match the starting board")
+	}
+}
+
+func TestFENErrors(t *testing.T) {
+	bad := []string{
+		"",
+		"X:W21:B1",
+		"W:W33:B1",
+		"W:W21:B21",
+		"W:Wa:B1",
+	}
+	for _, fen := range bad {
+		if _, _, err := ParseFEN(fen); err == nil {
+			t.Errorf("Expected error for %q", fen)
+		}
+	}
+}
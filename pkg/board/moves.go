@@ -20,6 +20,9 @@ type Move interface {
 
 type PlainMove struct {
 	Start, End Position
+	// path holds the squares between Start and End, which must be empty.
+	// It is only set for the long moves of flying kings.
+	path uint64
 }
 
 func CreatePlainMove(startrow, startcol, endrow, endcol int) PlainMove {
@@ -52,7 +55,8 @@ func (pm PlainMove) IsValid(b *Board, color PieceColor) bool {
 
 	return b.isSpotEmpty(&pm.End) &&
 		// return empty &&
-		(p != nil && p.Color == color)
+		(p != nil && p.Color == color) &&
+		b.isPathClear(pm.path)
 }
 
 func (pm PlainMove) DoMove(b *Board) *Position {
@@ -82,12 +86,17 @@ type JumpMove struct {
 }
 
 func CreateJump(row, col, skiprow, skipcol, newrow, newcol int) *JumpMove {
-	m := CreateMove(row, col, newrow, newcol)
+	return American.createJump(row, col, skiprow, skipcol, newrow, newcol)
+}
+
+func (v *Variant) createJump(row, col, skiprow, skipcol, newrow, newcol int) *JumpMove {
+	m := v.createMove(row, col, newrow, newcol)
 	if m != nil {
+		jump := v.NewPosition(skiprow, skipcol)
 		j := &JumpMove{
 			Move:     *m,
-			Jump:     *NewPosition(skiprow, skipcol),
-			jumpmask: getMaskForPosition(skiprow, skipcol),
+			Jump:     *jump,
+			jumpmask: jump.mask,
 		}
 		return j
 	}
@@ -116,7 +125,8 @@ func (j JumpMove) IsValid(b *Board, color PieceColor) bool {
 	jspot := b.GetPiece(&j.Jump)
 	return b.isSpotEmpty(&j.Move.End) &&
 		(p != nil && p.Color == color) &&
-		(jspot != nil && jspot.Color != color)
+		(jspot != nil && jspot.Color != color) &&
+		b.isPathClear(j.Move.path)
 }
 
 func (j JumpMove) DoMove(b *Board) *Position {
@@ -201,18 +211,22 @@ func (mjm MultiMove) IsInteresting(b *Board, afterRun bool) bool {
 }
 
 func CreateMove(row, col, newrow, newcol int) *PlainMove {
-	if newcol >= 0 && newcol < BoardCols && newrow >= 0 && newrow < BoardRows {
+	return American.createMove(row, col, newrow, newcol)
+}
+
+func (v *Variant) createMove(row, col, newrow, newcol int) *PlainMove {
+	if v.IsPlayable(newrow, newcol) {
 		return &PlainMove{
-			Start: *NewPosition(row, col),
-			End:   *NewPosition(newrow, newcol),
+			Start: *v.NewPosition(row, col),
+			End:   *v.NewPosition(newrow, newcol),
 		}
 	}
 
 	return nil
 }
 
-func createAppendMove(row, col, newrow, newcol int, moves []PlainMove) []PlainMove {
-	m := CreateMove(row, col, newrow, newcol)
+func (v *Variant) createAppendMove(row, col, newrow, newcol int, moves []PlainMove) []PlainMove {
+	m := v.createMove(row, col, newrow, newcol)
 	if m != nil {
 		moves = append(moves, *m)
 	}
@@ -220,37 +234,82 @@ func createAppendMove(row, col, newrow, newcol int, moves []PlainMove) []PlainMo
 	return moves
 }
 
-func createMovesInRow(row, col, dir int, moves []PlainMove) []PlainMove {
-	moves = createAppendMove(row, col, row+dir, col-1, moves)
-	moves = createAppendMove(row, col, row+dir, col+1, moves)
+func (v *Variant) createMovesInRow(row, col, dir int, moves []PlainMove) []PlainMove {
+	moves = v.createAppendMove(row, col, row+dir, col-1, moves)
+	moves = v.createAppendMove(row, col, row+dir, col+1, moves)
 
 	return moves
 }
 
-func createKingMoves(row, col int, moves []PlainMove) []PlainMove {
-	moves = createMovesInRow(row, col, RedDirection, moves)
-	moves = createMovesInRow(row, col, BlueDirection, moves)
+func (v *Variant) createKingMoves(row, col int, moves []PlainMove) []PlainMove {
+	moves = v.createMovesInRow(row, col, RedDirection, moves)
+	moves = v.createMovesInRow(row, col, BlueDirection, moves)
 
 	return moves
 }
-func createAppendJump(row, col, skiprow, skipcol, newrow, newcol int, jumps []JumpMove) []JumpMove {
-	j := CreateJump(row, col, skiprow, skipcol, newrow, newcol)
+func (v *Variant) createAppendJump(row, col, skiprow, skipcol, newrow, newcol int, jumps []JumpMove) []JumpMove {
+	j := v.createJump(row, col, skiprow, skipcol, newrow, newcol)
 	if j != nil {
 		jumps = append(jumps, *j)
 	}
 
 	return jumps
 }
-func createJumps(row, col, dir int, jumps []JumpMove) []JumpMove {
-	jumps = createAppendJump(row, col, row+dir, col+1, row+2*dir, col+2, jumps)
-	jumps = createAppendJump(row, col, row+dir, col-1, row+2*dir, col-2, jumps)
+func (v *Variant) createJumps(row, col, dir int, jumps []JumpMove) []JumpMove {
+	jumps = v.createAppendJump(row, col, row+dir, col+1, row+2*dir, col+2, jumps)
+	jumps = v.createAppendJump(row, col, row+dir, col-1, row+2*dir, col-2, jumps)
 
 	return jumps
 }
 
-func createKingJumps(row, col int, jumps []JumpMove) []JumpMove {
-	jumps = createJumps(row, col, RedDirection, jumps)
-	jumps = createJumps(row, col, BlueDirection, jumps)
+func (v *Variant) createKingJumps(row, col int, jumps []JumpMove) []JumpMove {
+	jumps = v.createJumps(row, col, RedDirection, jumps)
+	jumps = v.createJumps(row, col, BlueDirection, jumps)
+
+	return jumps
+}
+
+// kingDirections are the diagonals a flying king can move along
+var kingDirections = [][2]int{
+	{RedDirection, -1},
+	{RedDirection, 1},
+	{BlueDirection, -1},
+	{BlueDirection, 1},
+}
+
+// createFlyingKingMoves creates moves of any distance along each diagonal
+func (v *Variant) createFlyingKingMoves(row, col int, moves []PlainMove) []PlainMove {
+	for _, dir := range kingDirections {
+		var path uint64
+		for dist := 1; v.IsPlayable(row+dist*dir[0], col+dist*dir[1]); dist++ {
+			m := v.createMove(row, col, row+dist*dir[0], col+dist*dir[1])
+			m.path = path
+			moves = append(moves, *m)
+			path |= m.End.mask
+		}
+	}
+
+	return moves
+}
+
+// createFlyingKingJumps creates captures that jump a piece any distance away
+// along a diagonal and land on any square beyond it
+func (v *Variant) createFlyingKingJumps(row, col int, jumps []JumpMove) []JumpMove {
+	for _, dir := range kingDirections {
+		var before uint64
+		for skip := 1; v.IsPlayable(row+skip*dir[0], col+skip*dir[1]); skip++ {
+			skiprow, skipcol := row+skip*dir[0], col+skip*dir[1]
+
+			var after uint64
+			for land := skip + 1; v.IsPlayable(row+land*dir[0], col+land*dir[1]); land++ {
+				j := v.createJump(row, col, skiprow, skipcol, row+land*dir[0], col+land*dir[1])
+				j.Move.path = before | after
+				jumps = append(jumps, *j)
+				after |= j.Move.End.mask
+			}
+			before |= getMaskForBoard(skiprow, skipcol, v.Cols)
+		}
+	}
 
 	return jumps
 }
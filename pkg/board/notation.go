@@ -0,0 +1,149 @@
+package board
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NumSquares is the number of playable squares on an American board
+const NumSquares = BoardRows * BoardCols / 2
+
+// Square returns the standard square number of the position. Squares are
+// numbered from 1 starting from row 0, which is Red's side, so Red plays the
+// part of Black in the usual notation.
+func (p Position) Square() int {
+	return getSpotForMask(p.mask) + 1
+}
+
+// PositionFromSquare returns the position of a standard square number on an
+// American board
+func PositionFromSquare(square int) (*Position, error) {
+	return American.PositionFromSquare(square)
+}
+
+// PositionFromSquare returns the position of a standard square number
+func (v *Variant) PositionFromSquare(square int) (*Position, error) {
+	if square < 1 || square > v.NumSquares() {
+		return nil, fmt.Errorf("square %d out of range", square)
+	}
+	pos := *v.positions[square-1]
+	return &pos, nil
+}
+
+// MoveSquares returns the square the move starts on followed by every square
+// the piece lands on
+func MoveSquares(m Move) []int {
+	squares := []int{m.GetStart().Square()}
+	for _, pos := range moveLandings(m) {
+		squares = append(squares, pos.Square())
+	}
+	return squares
+}
+
+func moveLandings(m Move) []*Position {
+	var landings []*Position
+	switch mm := m.(type) {
+	case *MultiMove:
+		for _, sub := range mm.Moves {
+			landings = append(landings, moveLandings(sub)...)
+		}
+	case MultiMove:
+		for _, sub := range mm.Moves {
+			landings = append(landings, moveLandings(sub)...)
+		}
+	default:
+		landings = append(landings, m.GetEnd())
+	}
+	return landings
+}
+
+// IsCapture returns true if the move jumps over at least one piece
+func IsCapture(m Move) bool {
+	return len(m.GetJumpedPositions()) > 0
+}
+
+// MoveNotation returns the move in standard notation, such as "11-15" for a
+// plain move or "22x15x8" for a capture
+func MoveNotation(m Move) string {
+	sep := "-"
+	if IsCapture(m) {
+		sep = "x"
+	}
+
+	squares := MoveSquares(m)
+	parts := make([]string, len(squares))
+	for i, sq := range squares {
+		parts[i] = strconv.Itoa(sq)
+	}
+	return strings.Join(parts, sep)
+}
+
+// ParseMoveSquares splits a move in standard notation into its squares
+func ParseMoveSquares(notation string) ([]int, error) {
+	fields := strings.FieldsFunc(notation, func(r rune) bool {
+		return r == '-' || r == 'x' || r == 'X' || r == ':'
+	})
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("invalid move %q", notation)
+	}
+
+	squares := make([]int, len(fields))
+	for i, f := range fields {
+		sq, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid move %q", notation)
+		}
+		if sq < 1 {
+			return nil, fmt.Errorf("invalid move %q: square %d out of range", notation, sq)
+		}
+		squares[i] = sq
+	}
+	return squares, nil
+}
+
+// FindMove returns the move from moves that matches the notation. Captures
+// may be given with every landing square or just the start and end squares,
+// as long as that is not ambiguous.
+func FindMove(moves MoveList, notation string) (Move, error) {
+	squares, err := ParseMoveSquares(notation)
+	if err != nil {
+		return nil, err
+	}
+
+	var found Move
+	matches := 0
+	for _, m := range moves {
+		ms := MoveSquares(m)
+		full := equalSquares(ms, squares)
+		short := len(squares) == 2 && ms[0] == squares[0] && ms[len(ms)-1] == squares[1]
+		if full {
+			return m, nil
+		}
+		if short {
+			found = m
+			matches++
+		}
+	}
+
+	switch matches {
+	case 0:
+		return nil, fmt.Errorf("move %q is not legal", notation)
+	case 1:
+		return found, nil
+	default:
+		return nil, fmt.Errorf("move %q is ambiguous", notation)
+	}
+}
+
+func equalSquares(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
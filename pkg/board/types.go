@@ -1,6 +1,10 @@
 package board
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"os"
+)
 
 // PieceColor represents the color of a piece
 type PieceColor int
@@ -31,6 +35,11 @@ type Piece struct {
 }
 
 func (p *Piece) Dump() {
+	p.DumpTo(os.Stdout)
+}
+
+// DumpTo writes the piece to w
+func (p *Piece) DumpTo(w io.Writer) {
 	var pieceString string
 	switch p.Color {
 	case Red:
@@ -45,7 +54,7 @@ func (p *Piece) Dump() {
 		pieceString += " "
 	}
 
-	fmt.Print(pieceString)
+	fmt.Fprint(w, pieceString)
 }
 
 var (
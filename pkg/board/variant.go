@@ -0,0 +1,199 @@
+package board
+
+import "fmt"
+
+// Variant describes the rules of a checkers variant. The move tables for the
+// variant are built once by NewVariant and shared by every board using it.
+type Variant struct {
+	Name string
+	// Rows and Cols give the size of the board. Only the playable squares
+	// are stored, one bit each, so the board can have at most 64 playable
+	// squares.
+	Rows, Cols int
+	// PieceRows is the number of rows of men each side starts with
+	PieceRows int
+	// FirstTurn is the color that moves first
+	FirstTurn PieceColor
+	// FlyingKings lets kings move and capture any distance along a diagonal
+	FlyingKings bool
+	// MenCaptureBackward lets men capture backwards as well as forwards
+	MenCaptureBackward bool
+	// MaxCapture forces the capture that takes the most pieces
+	MaxCapture bool
+	// PromoteDuringCapture crowns a man that reaches the far row in the
+	// middle of a capture, and lets it carry on capturing as a king
+	PromoteDuringCapture bool
+	// GameType is the PDN GameType tag for the variant
+	GameType int
+
+	positions []*Position
+	moves     []Moves
+}
+
+var (
+	// American is American checkers, also called English draughts
+	American = NewVariant(Variant{
+		Name:      "American",
+		Rows:      8,
+		Cols:      8,
+		PieceRows: 3,
+		FirstTurn: Red,
+		GameType:  21,
+	})
+	// International is international draughts, played on a 10x10 board
+	International = NewVariant(Variant{
+		Name:               "International",
+		Rows:               10,
+		Cols:               10,
+		PieceRows:          4,
+		FirstTurn:          Blue,
+		FlyingKings:        true,
+		MenCaptureBackward: true,
+		MaxCapture:         true,
+		GameType:           20,
+	})
+	// Brazilian is international draughts on an 8x8 board
+	Brazilian = NewVariant(Variant{
+		Name:               "Brazilian",
+		Rows:               8,
+		Cols:               8,
+		PieceRows:          3,
+		FirstTurn:          Blue,
+		FlyingKings:        true,
+		MenCaptureBackward: true,
+		MaxCapture:         true,
+		GameType:           26,
+	})
+	// Russian draughts has no majority capture rule but crowns men in the
+	// middle of a capture
+	Russian = NewVariant(Variant{
+		Name:                 "Russian",
+		Rows:                 8,
+		Cols:                 8,
+		PieceRows:            3,
+		FirstTurn:            Blue,
+		FlyingKings:          true,
+		MenCaptureBackward:   true,
+		PromoteDuringCapture: true,
+		GameType:             25,
+	})
+	// Pool is American pool checkers
+	Pool = NewVariant(Variant{
+		Name:               "Pool",
+		Rows:               8,
+		Cols:               8,
+		PieceRows:          3,
+		FirstTurn:          Red,
+		FlyingKings:        true,
+		MenCaptureBackward: true,
+		GameType:           23,
+	})
+
+	Variants = []*Variant{American, International, Brazilian, Russian, Pool}
+)
+
+// NewVariant creates a variant from its rules and builds its move tables
+func NewVariant(rules Variant) *Variant {
+	if rules.Rows <= 0 || rules.Cols <= 0 || rules.Cols%2 != 0 || rules.Rows*rules.Cols/2 > 64 {
+		panic(fmt.Sprintf("unsupported board size %dx%d", rules.Rows, rules.Cols))
+	}
+
+	v := &rules
+	v.positions = make([]*Position, 0, v.Rows*v.Cols/2)
+	v.moves = make([]Moves, v.Rows*v.Cols/2)
+	for row := 0; row < v.Rows; row++ {
+		for col := 0; col < v.Cols; col++ {
+			if v.IsPlayable(row, col) {
+				v.moves[getSpotForBoard(row, col, v.Cols)] = v.createMoves(row, col)
+				v.positions = append(v.positions, v.NewPosition(row, col))
+			}
+		}
+	}
+	return v
+}
+
+// VariantByName returns the variant with the given name
+func VariantByName(name string) (*Variant, error) {
+	for _, v := range Variants {
+		if v.Name == name {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown variant %q", name)
+}
+
+// VariantForGameType returns the variant for a PDN GameType tag
+func VariantForGameType(gameType int) (*Variant, error) {
+	for _, v := range Variants {
+		if v.GameType == gameType {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported game type %d", gameType)
+}
+
+func (v *Variant) String() string {
+	return v.Name
+}
+
+// NewPosition creates a position on this variant's board
+func (v *Variant) NewPosition(row, col int) *Position {
+	return &Position{row, col, getMaskForBoard(row, col, v.Cols)}
+}
+
+// IsPlayable returns true for the dark squares pieces can stand on
+func (v *Variant) IsPlayable(row, col int) bool {
+	return row >= 0 && row < v.Rows && col >= 0 && col < v.Cols && (row+col)%2 != 0
+}
+
+// NumSquares returns the number of playable squares
+func (v *Variant) NumSquares() int {
+	return len(v.positions)
+}
+
+// Positions returns all the playable positions in square number order
+func (v *Variant) Positions() []*Position {
+	return v.positions
+}
+
+// IsPromotionRow returns true if a man of the color is crowned on the row
+func (v *Variant) IsPromotionRow(row int, color PieceColor) bool {
+	if color == Red {
+		return row == v.Rows-1
+	}
+	return row == 0
+}
+
+func (v *Variant) createMoves(row, col int) Moves {
+	// Create a new Moves instance
+	moves := Moves{
+		Moves:     make(map[PieceColor][]PlainMove),
+		Jumps:     make(map[PieceColor][]JumpMove),
+		KingMoves: []PlainMove{},
+		KingJumps: []JumpMove{},
+	}
+
+	redJumps := v.createJumps(row, col, RedDirection, []JumpMove{})
+	blueJumps := v.createJumps(row, col, BlueDirection, []JumpMove{})
+	if v.MenCaptureBackward {
+		redJumps = v.createJumps(row, col, BlueDirection, redJumps)
+		blueJumps = v.createJumps(row, col, RedDirection, blueJumps)
+	}
+	moves.Jumps[Red] = redJumps
+	moves.Jumps[Blue] = blueJumps
+
+	redMoves := v.createMovesInRow(row, col, RedDirection, []PlainMove{})
+	blueMoves := v.createMovesInRow(row, col, BlueDirection, []PlainMove{})
+	moves.Moves[Red] = redMoves
+	moves.Moves[Blue] = blueMoves
+
+	if v.FlyingKings {
+		moves.KingMoves = v.createFlyingKingMoves(row, col, moves.KingMoves)
+		moves.KingJumps = v.createFlyingKingJumps(row, col, moves.KingJumps)
+	} else {
+		moves.KingMoves = v.createKingMoves(row, col, moves.KingMoves)
+		moves.KingJumps = v.createKingJumps(row, col, moves.KingJumps)
+	}
+
+	return moves
+}
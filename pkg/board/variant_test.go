@@ -0,0 +1,69 @@
+package board
+
+import (
+	"sort"
+	"testing"
+)
+
+func legalNotations(t *testing.T, v *Variant, fen string) []string {
+	t.Helper()
+	b, turn, err := v.ParseFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var notations []string
+	for _, m := range b.GetAllLegalMovesForColor(turn) {
+		notations = append(notations, MoveNotation(m))
+	}
+	sort.Strings(notations)
+	return notations
+}
+
+func assertMoves(t *testing.T, v *Variant, fen string, want ...string) {
+	t.Helper()
+	got := legalNotations(t, v, fen)
+	sort.Strings(want)
+	if len(got) != len(want) {
+		t.Fatalf("%s %s: got moves %v, want %v", v, fen, got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("%s %s: got moves %v, want %v", v, fen, got, want)
+		}
+	}
+}
+
+func TestFlyingKings(t *testing.T) {
+	assertMoves(t, American, "W:WK29:B", "29-25")
+	assertMoves(t, Russian, "W:WK29:B", "29-25", "29-22", "29-18", "29-15", "29-11", "29-8", "29-4")
+	// A flying king may land on any empty square past the captured piece
+	assertMoves(t, Russian, "W:WK29:B15", "29x11", "29x8", "29x4")
+	// but not jump two pieces in a row
+	assertMoves(t, Russian, "W:WK29:B15,11", "29-25", "29-22", "29-18")
+}
+
+func TestMenCaptureBackward(t *testing.T) {
+	assertMoves(t, American, "W:W18:B22", "18-14", "18-15")
+	assertMoves(t, Brazilian, "W:W18:B22", "18x25")
+}
+
+func TestMaxCapture(t *testing.T) {
+	// 27x20 takes a single piece, the others take two
+	fen := "W:W27,28:B23,14,24"
+	assertMoves(t, Russian, fen, "27x18x9", "27x20", "28x19x26")
+	assertMoves(t, Brazilian, fen, "27x18x9", "28x19x26")
+}
+
+func TestPromoteDuringCapture(t *testing.T) {
+	fen := "W:W9:B6,7"
+	assertMoves(t, Russian, fen, "9x2x11", "9x2x16", "9x2x20")
+	assertMoves(t, Brazilian, fen, "9x2x11")
+
+	b, turn, _ := Russian.ParseFEN(fen)
+	m := b.GetAllLegalMovesForColor(turn)[0]
+	m.DoMove(b)
+	b.Promote(m, turn)
+	if p := b.GetPiece(m.GetEnd()); p == nil || !p.IsKing {
+		t.Errorf("Man not crowned after passing the far row")
+	}
+}
@@ -0,0 +1,68 @@
+package board
+
+import (
+	"math/bits"
+	"math/rand"
+)
+
+// zobristSeed is fixed so hashes are stable between runs and can be stored
+const zobristSeed = 0x5eed_c4ec_3e75
+
+var (
+	// zobristPieces holds a key per color, piece kind and square
+	zobristPieces [2][2][64]uint64
+	// zobristBlueToMove is mixed into the hash when Blue is the side to move
+	zobristBlueToMove uint64
+)
+
+func init() {
+	r := rand.New(rand.NewSource(zobristSeed))
+	for color := range zobristPieces {
+		for kind := range zobristPieces[color] {
+			for spot := range zobristPieces[color][kind] {
+				zobristPieces[color][kind][spot] = r.Uint64()
+			}
+		}
+	}
+	zobristBlueToMove = r.Uint64()
+}
+
+func zobristKey(piece *Piece, mask uint64) uint64 {
+	kind := 0
+	if piece.IsKing {
+		kind = 1
+	}
+	return zobristPieces[piece.Color][kind][bits.TrailingZeros64(mask)]
+}
+
+// Hash returns the Zobrist hash of the pieces on the board. It is updated
+// incrementally as pieces are set, moved, removed and kinged.
+func (b *Board) Hash() uint64 {
+	return b.hash
+}
+
+// PositionHash returns the hash of the board combined with the side to move
+func (b *Board) PositionHash(turn PieceColor) uint64 {
+	if turn == Blue {
+		return b.hash ^ zobristBlueToMove
+	}
+	return b.hash
+}
+
+// ComputeHash calculates the Zobrist hash from scratch
+func (b *Board) ComputeHash() uint64 {
+	var h uint64
+	for spot := 0; spot < 64; spot++ {
+		mask := uint64(1) << spot
+		if p := b.getPieceByMask(mask); p != nil {
+			h ^= zobristKey(p, mask)
+		}
+	}
+	return h
+}
+
+// Rehash recomputes the stored hash. It must be called after the masks
+// have been changed directly instead of through the board methods.
+func (b *Board) Rehash() {
+	b.hash = b.ComputeHash()
+}
@@ -0,0 +1,41 @@
+package board
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestZobristIncremental(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for gameNum := 0; gameNum < 20; gameNum++ {
+		b := NewBoard()
+		color := Red
+		for ply := 0; ply < 200; ply++ {
+			moves := b.GetAllLegalMovesForColor(color)
+			if len(moves) == 0 {
+				break
+			}
+			pos := moves[r.Intn(len(moves))].DoMove(b)
+			if pos.Row == 0 || pos.Row == BoardRows-1 {
+				b.KingMe(pos)
+			}
+			if b.Hash() != b.ComputeHash() {
+				t.Fatalf("game %d ply %d: incremental hash %x != computed %x", gameNum, ply, b.Hash(), b.ComputeHash())
+			}
+			color = color.NextColor()
+		}
+	}
+}
+
+func TestZobristSideToMove(t *testing.T) {
+	b := NewBoard()
+	if b.PositionHash(Red) == b.PositionHash(Blue) {
+		t.Errorf("Side to move not part of the position hash")
+	}
+
+	other := NewBoard()
+	if b.Hash() != other.Hash() {
+		t.Errorf("Identical boards hash differently")
+	}
+}
@@ -0,0 +1,236 @@
+// Package eval scores positions that are not yet decided, for searches that
+// cannot play every line out to the end of the game
+package eval
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ytaragin/checkers/pkg/board"
+)
+
+// Weights are the weights of the evaluation terms. Scores are measured in
+// men, so with a Man weight of 1 a score of 2 is worth about two men.
+type Weights struct {
+	// Man and King are the values of the pieces
+	Man, King float64
+	// Advancement rewards men for getting close to being crowned. A man
+	// earns the square of the fraction of the board it has crossed.
+	Advancement float64
+	// BackRank rewards men left on their own back row, where they stop the
+	// opponent's men from being crowned. It only counts while the opponent
+	// still has men.
+	BackRank float64
+	// Center rewards pieces on the central squares
+	Center float64
+	// Mobility rewards each legal move more than the opponent has. Counting
+	// the moves costs a move generation per side.
+	Mobility float64
+	// Tempo rewards the total number of rows the men have advanced more
+	// than the opponent's. It grows as pieces come off, since having the
+	// move in hand decides many endgames.
+	Tempo float64
+}
+
+// Material counts only the pieces
+var Material = Weights{Man: 1, King: 1.5}
+
+// DefaultWeights are hand tuned weights for all the terms
+var DefaultWeights = Weights{
+	Man:         1,
+	King:        1.5,
+	Advancement: 0.25,
+	BackRank:    0.1,
+	Center:      0.05,
+	Mobility:    0.02,
+	Tempo:       0.01,
+}
+
+// Evaluate scores the board from the point of view of color using the
+// default weights
+func Evaluate(b *board.Board, color board.PieceColor) float64 {
+	return DefaultWeights.Evaluate(b, color)
+}
+
+// Evaluate scores the board from the point of view of color
+func (w Weights) Evaluate(b *board.Board, color board.PieceColor) float64 {
+	t := w.Terms(b, color)
+	return w.Man*t.Men + w.King*t.Kings + w.Advancement*t.Advancement +
+		w.BackRank*t.BackRank + w.Center*t.Center + w.Mobility*t.Mobility +
+		w.Tempo*t.Tempo
+}
+
+// Terms are the unweighted terms of an evaluation. Each is the difference
+// between the two sides.
+type Terms struct {
+	Men, Kings  float64
+	Advancement float64
+	BackRank    float64
+	Center      float64
+	Mobility    float64
+	Tempo       float64
+}
+
+// Terms works out the terms of the evaluation for color. Mobility is only
+// counted if its weight is set.
+func (w Weights) Terms(b *board.Board, color board.PieceColor) Terms {
+	v := b.Variant()
+	m := masksFor(v)
+	opp := color.NextColor()
+
+	mine, theirs := colorMask(b, color), colorMask(b, opp)
+	myMen, theirMen := mine&^b.Kings, theirs&^b.Kings
+
+	var t Terms
+	t.Men = float64(bits.OnesCount64(myMen) - bits.OnesCount64(theirMen))
+	t.Kings = float64(bits.OnesCount64(mine&b.Kings) - bits.OnesCount64(theirs&b.Kings))
+
+	myTempo, myAdvance := m.advancement(myMen, color)
+	theirTempo, theirAdvance := m.advancement(theirMen, opp)
+	t.Advancement = myAdvance - theirAdvance
+
+	pieces := bits.OnesCount64(mine | theirs)
+	phase := 1 - float64(pieces)/float64(m.startPieces)
+	t.Tempo = float64(myTempo-theirTempo) * phase
+
+	if theirMen != 0 {
+		t.BackRank += float64(bits.OnesCount64(myMen & m.backRank[color]))
+	}
+	if myMen != 0 {
+		t.BackRank -= float64(bits.OnesCount64(theirMen & m.backRank[opp]))
+	}
+
+	t.Center = float64(bits.OnesCount64(mine&m.center) - bits.OnesCount64(theirs&m.center))
+
+	if w.Mobility != 0 {
+		t.Mobility = float64(len(b.GetAllLegalMovesForColor(color)) - len(b.GetAllLegalMovesForColor(opp)))
+	}
+	return t
+}
+
+// WinProbability turns a score into the chance of winning, so that an
+// evaluation can stand in for the result of a playout. A lead of a man is
+// worth about a 75% chance.
+func WinProbability(score float64) float64 {
+	return 1 / (1 + math.Exp(-score*math.Log(3)))
+}
+
+func colorMask(b *board.Board, color board.PieceColor) uint64 {
+	if color == board.Blue {
+		return b.BlueMask
+	}
+	return b.RedMask
+}
+
+// variantMasks are the squares each term looks at on a variant's board
+type variantMasks struct {
+	rows        int
+	row         []int
+	backRank    map[board.PieceColor]uint64
+	center      uint64
+	startPieces int
+}
+
+var masks sync.Map
+
+func masksFor(v *board.Variant) *variantMasks {
+	if m, ok := masks.Load(v); ok {
+		return m.(*variantMasks)
+	}
+
+	m := &variantMasks{
+		rows:        v.Rows,
+		row:         make([]int, v.NumSquares()),
+		backRank:    make(map[board.PieceColor]uint64),
+		startPieces: v.PieceRows * v.Cols,
+	}
+	for _, pos := range v.Positions() {
+		bit := uint64(1) << (pos.Square() - 1)
+		m.row[pos.Square()-1] = pos.Row
+		switch pos.Row {
+		case 0:
+			m.backRank[board.Red] |= bit
+		case v.Rows - 1:
+			m.backRank[board.Blue] |= bit
+		}
+		// The center is the middle two rows without the side columns
+		if (pos.Row == v.Rows/2-1 || pos.Row == v.Rows/2) && pos.Col >= 2 && pos.Col < v.Cols-2 {
+			m.center |= bit
+		}
+	}
+	actual, _ := masks.LoadOrStore(v, m)
+	return actual.(*variantMasks)
+}
+
+// advancement returns the total rows the men have advanced, and the sum of
+// the squares of the fraction of the board each has crossed
+func (m *variantMasks) advancement(men uint64, color board.PieceColor) (int, float64) {
+	tempo := 0
+	advance := 0.0
+	for men != 0 {
+		sq := bits.TrailingZeros64(men)
+		men &= men - 1
+
+		rows := m.row[sq]
+		if color == board.Blue {
+			rows = m.rows - 1 - rows
+		}
+		tempo += rows
+		f := float64(rows) / float64(m.rows-1)
+		advance += f * f
+	}
+	return tempo, advance
+}
+
+// ParseWeights reads weights written as "name=value,...", such as
+// "king=1.6,mobility=0.05". The weights may also be separated by semicolons.
+// Weights that are not given keep their default, and "material" stands for
+// the Material weights.
+func ParseWeights(s string) (Weights, error) {
+	w := DefaultWeights
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "":
+		return w, nil
+	case "material":
+		return Material, nil
+	}
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' })
+	for _, field := range fields {
+		name, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			return w, fmt.Errorf("invalid weight %q", field)
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return w, fmt.Errorf("invalid weight %q: %v", field, err)
+		}
+		switch strings.ToLower(name) {
+		case "man":
+			w.Man = f
+		case "king":
+			w.King = f
+		case "advancement":
+			w.Advancement = f
+		case "backrank":
+			w.BackRank = f
+		case "center":
+			w.Center = f
+		case "mobility":
+			w.Mobility = f
+		case "tempo":
+			w.Tempo = f
+		default:
+			return w, fmt.Errorf("unknown weight %q", name)
+		}
+	}
+	return w, nil
+}
+
+func (w Weights) String() string {
+	return fmt.Sprintf("man=%g,king=%g,advancement=%g,backrank=%g,center=%g,mobility=%g,tempo=%g",
+		w.Man, w.King, w.Advancement, w.BackRank, w.Center, w.Mobility, w.Tempo)
+}
@@ -0,0 +1,64 @@
+package eval
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ytaragin/checkers/pkg/board"
+)
+
+func TestStartIsBalanced(t *testing.T) {
+	for _, v := range board.Variants {
+		b := board.NewVariantBoard(v)
+		for _, color := range []board.PieceColor{board.Red, board.Blue} {
+			if score := Evaluate(b, color); math.Abs(score) > 1e-9 {
+				t.Errorf("%s: expected the start to score 0 for %s, got %f", v, color.Name(), score)
+			}
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	b, _, err := board.ParseFEN("W:W32,K31:B1,2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Blue has a man and a king against two men
+	if score := Material.Evaluate(b, board.Blue); score != 0.5 {
+		t.Errorf("expected a material score of 0.5, got %f", score)
+	}
+	if red, blue := DefaultWeights.Evaluate(b, board.Red), DefaultWeights.Evaluate(b, board.Blue); red != -blue {
+		t.Errorf("expected the scores to be opposite, got %f and %f", red, blue)
+	}
+
+	terms := DefaultWeights.Terms(b, board.Red)
+	if terms.BackRank != 2-1 {
+		t.Errorf("expected Red's two back rank men against Blue's one, got %f", terms.BackRank)
+	}
+}
+
+func TestParseWeights(t *testing.T) {
+	w, err := ParseWeights("king=2;mobility=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.King != 2 || w.Mobility != 0 || w.Man != DefaultWeights.Man {
+		t.Errorf("unexpected weights %s", w)
+	}
+	if _, err := ParseWeights("queen=9"); err == nil {
+		t.Errorf("expected an error for an unknown weight")
+	}
+	if w, _ := ParseWeights("material"); w != Material {
+		t.Errorf("expected the material weights, got %s", w)
+	}
+}
+
+func TestWinProbability(t *testing.T) {
+	if p := WinProbability(0); p != 0.5 {
+		t.Errorf("expected an even score to be 0.5, got %f", p)
+	}
+	if p := WinProbability(1); math.Abs(p-0.75) > 1e-9 {
+		t.Errorf("expected a man up to be 0.75, got %f", p)
+	}
+}
@@ -2,12 +2,18 @@ package game
 
 import (
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/ytaragin/checkers/pkg/board"
 )
 
 type GameState int
 
+// DefaultRepetitionLimit is the number of times a position has to occur for
+// the game to be drawn
+const DefaultRepetitionLimit = 3
+
 const (
 	Ongoing GameState = iota
 	BlueWin
@@ -22,16 +28,40 @@ type Game struct {
 	countSinceLastInteresting int
 	moveCount                 int
 	lastMove                  board.Move
+	history                   *historyEntry
+	redo                      *historyEntry
+	repetitionLimit           int
+	// repetitions is how many times the current position occurred before
+	repetitions int
+	resigned    bool
+}
+
+// historyEntry records a move and everything needed to take it back. Entries
+// are never modified once created, so copies of a game share their history.
+type historyEntry struct {
+	move                      board.Move
+	gameboard                 board.Board
+	nextLegalMoves            []board.Move
+	countSinceLastInteresting int
+	lastMove                  board.Move
+	repetitions               int
+	prev                      *historyEntry
 }
 
 func NewGame() *Game {
-	b := board.NewBoard()
+	return NewVariantGame(board.American)
+}
+
+// NewVariantGame creates a game played by the rules of the variant
+func NewVariantGame(v *board.Variant) *Game {
+	b := board.NewVariantBoard(v)
 	game := &Game{
 		gameboard:                 *b,
-		nextTurn:                  board.Red,
-		nextLegalMoves:            b.GetAllLegalMovesForColor(board.Red),
+		nextTurn:                  v.FirstTurn,
+		nextLegalMoves:            b.GetAllLegalMovesForColor(v.FirstTurn),
 		countSinceLastInteresting: 0,
 		moveCount:                 0,
+		repetitionLimit:           DefaultRepetitionLimit,
 	}
 
 	return game
@@ -45,9 +75,34 @@ func InitGameFromBoard(b *board.Board, nextTurn board.PieceColor, sinceInterstin
 		countSinceLastInteresting: sinceIntersting,
 		moveCount:                 1,
 		lastMove:                  board.CreatePlainMove(0, 0, 1, 1),
+		repetitionLimit:           DefaultRepetitionLimit,
 	}
 }
 
+// InitGameFromFEN creates a game from a FEN position such as "B:W21-32:B1-12"
+func InitGameFromFEN(fen string) (*Game, error) {
+	return InitVariantGameFromFEN(board.American, fen)
+}
+
+// InitVariantGameFromFEN creates a game of the variant from a FEN position
+func InitVariantGameFromFEN(v *board.Variant, fen string) (*Game, error) {
+	b, turn, err := v.ParseFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+	return InitGameFromBoard(b, turn, 0), nil
+}
+
+// Variant returns the rules the game is played by
+func (g *Game) Variant() *board.Variant {
+	return g.gameboard.Variant()
+}
+
+// FEN returns the current position in FEN
+func (g *Game) FEN() string {
+	return g.gameboard.FEN(g.nextTurn)
+}
+
 func (g *Game) Copy() *Game {
 	return &Game{
 		gameboard:                 g.gameboard,
@@ -56,23 +111,54 @@ func (g *Game) Copy() *Game {
 		countSinceLastInteresting: g.countSinceLastInteresting,
 		moveCount:                 g.moveCount,
 		lastMove:                  g.lastMove,
+		history:                   g.history,
+		redo:                      g.redo,
+		repetitionLimit:           g.repetitionLimit,
+		repetitions:               g.repetitions,
+		resigned:                  g.resigned,
 	}
 }
 
+// SetRepetitionLimit sets how many times a position has to occur for the game
+// to be drawn. A limit of 0 turns off repetition draws.
+func (g *Game) SetRepetitionLimit(limit int) {
+	g.repetitionLimit = limit
+}
+
+// Repetitions returns how many times the current position occurred before
+func (g *Game) Repetitions() int {
+	return g.repetitions
+}
+
 func (g *Game) GetState() GameState {
+	if g.resigned {
+		return g.winForOpponent()
+	}
 	if g.countSinceLastInteresting > 80 {
 		return Draw
 	}
+	if g.repetitionLimit > 0 && g.repetitions+1 >= g.repetitionLimit {
+		return Draw
+	}
 	if g.isCurrentLosing() {
-		if g.nextTurn == board.Red {
-			return BlueWin
-		} else {
-			return RedWin
-		}
+		return g.winForOpponent()
 	}
 	return Ongoing
 }
 
+func (g *Game) winForOpponent() GameState {
+	if g.nextTurn == board.Red {
+		return BlueWin
+	} else {
+		return RedWin
+	}
+}
+
+// Resign ends the game with a loss for the side to move
+func (g *Game) Resign() {
+	g.resigned = true
+}
+
 func (g *Game) MoveCount() int {
 	return g.moveCount
 }
@@ -81,19 +167,50 @@ func (g *Game) NextTurn() board.PieceColor {
 	return g.nextTurn
 }
 
+// GetBoard returns a copy of the current board
+func (g *Game) GetBoard() board.Board {
+	return g.gameboard
+}
+
+// Hash returns the Zobrist hash of the position including the side to move
+func (g *Game) Hash() uint64 {
+	return g.gameboard.PositionHash(g.nextTurn)
+}
+
 func (g *Game) GetWinner() board.PieceColor {
 	return g.nextTurn.NextColor()
 }
 
-func (g *Game) RunMove(m board.Move) {
+// RunMove plays m. It returns false, leaving the game as it was, if m is not
+// legal in the position.
+func (g *Game) RunMove(m board.Move) bool {
 	if !m.IsValid(&g.gameboard, g.nextTurn) {
-		return
+		return false
 	}
-	pos := m.DoMove(&g.gameboard)
-	if pos.Row == 0 || pos.Row == board.BoardRows-1 {
-		g.gameboard.KingMe(pos)
+	g.playMove(m)
+	return true
+}
+
+// playMove plays a move known to be legal, such as one of GetLegalMoves
+func (g *Game) playMove(m board.Move) {
+	g.runMove(m)
+	g.redo = nil
+}
+
+func (g *Game) runMove(m board.Move) {
+	g.history = &historyEntry{
+		move:                      m,
+		gameboard:                 g.gameboard,
+		nextLegalMoves:            g.nextLegalMoves,
+		countSinceLastInteresting: g.countSinceLastInteresting,
+		lastMove:                  g.lastMove,
+		repetitions:               g.repetitions,
+		prev:                      g.history,
 	}
 
+	m.DoMove(&g.gameboard)
+	g.gameboard.Promote(m, g.nextTurn)
+
 	if m.IsInteresting(&g.gameboard, true) {
 		g.countSinceLastInteresting = 0
 	} else {
@@ -104,7 +221,78 @@ func (g *Game) RunMove(m board.Move) {
 	g.nextLegalMoves = g.gameboard.GetAllLegalMovesForColor(g.nextTurn)
 	g.moveCount++
 	g.lastMove = m
+	g.repetitions = g.countRepetitions()
+
+}
+
+// countRepetitions counts the earlier occurrences of the current position.
+// Only positions with the same side to move since the last man move or
+// capture can be the same, so the search stops there. The boards are only
+// compared when their hashes match.
+func (g *Game) countRepetitions() int {
+	count := 0
+	hash := g.gameboard.Hash()
+	h := g.history
+	for back := 1; h != nil && back <= g.countSinceLastInteresting; back++ {
+		if back%2 == 0 && h.gameboard.Hash() == hash && h.gameboard == g.gameboard {
+			count++
+		}
+		h = h.prev
+	}
+	return count
+}
 
+// UndoMove takes back the last move. It returns false if there is no move to
+// take back.
+func (g *Game) UndoMove() bool {
+	h := g.history
+	if h == nil {
+		return false
+	}
+
+	g.gameboard = h.gameboard
+	g.nextLegalMoves = h.nextLegalMoves
+	g.countSinceLastInteresting = h.countSinceLastInteresting
+	g.lastMove = h.lastMove
+	g.repetitions = h.repetitions
+	g.nextTurn = g.nextTurn.NextColor()
+	g.moveCount--
+	g.history = h.prev
+
+	g.redo = &historyEntry{move: h.move, prev: g.redo}
+	return true
+}
+
+// RedoMove plays again the last move taken back by UndoMove. Playing any
+// other move clears the moves that can be redone.
+func (g *Game) RedoMove() bool {
+	r := g.redo
+	if r == nil {
+		return false
+	}
+	g.runMove(r.move)
+	g.redo = r.prev
+	return true
+}
+
+// History returns the moves played so far, oldest first
+func (g *Game) History() []board.Move {
+	var moves []board.Move
+	for h := g.history; h != nil; h = h.prev {
+		moves = append(moves, h.move)
+	}
+	for i, j := 0, len(moves)-1; i < j; i, j = i+1, j-1 {
+		moves[i], moves[j] = moves[j], moves[i]
+	}
+	return moves
+}
+
+// LastMove returns the last move played, or nil if there is none
+func (g *Game) LastMove() board.Move {
+	if g.history == nil {
+		return nil
+	}
+	return g.history.move
 }
 
 func (g *Game) GetLegalMoves() []board.Move {
@@ -116,7 +304,16 @@ func (g *Game) isCurrentLosing() bool {
 }
 
 func (g *Game) Dump() {
-	g.gameboard.Dump(g.lastMove.GetStart(), g.lastMove.GetEnd())
-	fmt.Printf("Next: %s Count: %d Int: %d\n", g.nextTurn.Name(), g.moveCount, g.countSinceLastInteresting)
+	g.DumpTo(os.Stdout)
+}
+
+// DumpTo writes the board and the state of the game to w
+func (g *Game) DumpTo(w io.Writer) {
+	var start, end *board.Position
+	if g.lastMove != nil {
+		start, end = g.lastMove.GetStart(), g.lastMove.GetEnd()
+	}
+	g.gameboard.DumpTo(w, start, end)
+	fmt.Fprintf(w, "Next: %s Count: %d Int: %d\n", g.nextTurn.Name(), g.moveCount, g.countSinceLastInteresting)
 
 }
@@ -0,0 +1,139 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/ytaragin/checkers/pkg/board"
+)
+
+func TestUndoRedo(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	g := NewGame()
+
+	var snapshots []Game
+	for g.GetState() == Ongoing {
+		snapshots = append(snapshots, *g)
+		moves := g.GetLegalMoves()
+		g.RunMove(moves[r.Intn(len(moves))])
+	}
+	final := *g
+	played := g.History()
+	if len(played) != len(snapshots) {
+		t.Fatalf("History has %d moves, played %d", len(played), len(snapshots))
+	}
+
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if !g.UndoMove() {
+			t.Fatalf("Undo failed at move %d", i)
+		}
+		assertSameGame(t, g, &snapshots[i])
+	}
+	if g.UndoMove() {
+		t.Errorf("Undo past the start of the game")
+	}
+
+	for i := range snapshots {
+		if !g.RedoMove() {
+			t.Fatalf("Redo failed at move %d", i)
+		}
+	}
+	if g.RedoMove() {
+		t.Errorf("Redo past the last move")
+	}
+	assertSameGame(t, g, &final)
+	if g.GetState() != final.GetState() {
+		t.Errorf("State after redo %d != %d", g.GetState(), final.GetState())
+	}
+}
+
+func TestRunMoveClearsRedo(t *testing.T) {
+	g := NewGame()
+	g.RunMove(g.GetLegalMoves()[0])
+	g.UndoMove()
+	g.RunMove(g.GetLegalMoves()[1])
+	if g.RedoMove() {
+		t.Errorf("Redo available after playing a new move")
+	}
+}
+
+func TestRunMoveRejectsIllegal(t *testing.T) {
+	g := NewGame()
+	g.RunMove(g.GetLegalMoves()[0])
+	// Red's opening move is not legal for Blue
+	illegal := NewGame().GetLegalMoves()[0]
+	if g.RunMove(illegal) {
+		t.Errorf("Expected %s to be rejected", board.MoveNotation(illegal))
+	}
+	if len(g.History()) != 1 || g.NextTurn() != board.Blue {
+		t.Errorf("Expected the game to be unchanged, got %d moves with %s to move",
+			len(g.History()), g.NextTurn().Name())
+	}
+}
+
+func TestCopySharesHistory(t *testing.T) {
+	g := NewGame()
+	g.RunMove(g.GetLegalMoves()[0])
+
+	c1 := g.Copy()
+	c2 := g.Copy()
+	c1.RunMove(c1.GetLegalMoves()[0])
+	c2.RunMove(c2.GetLegalMoves()[1])
+
+	if len(g.History()) != 1 || len(c1.History()) != 2 || len(c2.History()) != 2 {
+		t.Fatalf("Unexpected history lengths")
+	}
+	if c1.History()[1] == c2.History()[1] {
+		t.Errorf("Copies overwrote each other's history")
+	}
+}
+
+func assertSameGame(t *testing.T, got, want *Game) {
+	t.Helper()
+	if got.gameboard != want.gameboard ||
+		got.nextTurn != want.nextTurn ||
+		got.moveCount != want.moveCount ||
+		got.countSinceLastInteresting != want.countSinceLastInteresting ||
+		len(got.nextLegalMoves) != len(want.nextLegalMoves) {
+		t.Fatalf("Move %d: game not restored", want.moveCount)
+	}
+}
+
+func TestThreefoldRepetition(t *testing.T) {
+	g, err := InitGameFromFEN("B:WK32,21:BK1,12")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shuffle := []string{"1-5", "32-28", "5-1", "28-32"}
+	for round := 0; round < 2; round++ {
+		for _, notation := range shuffle {
+			if g.GetState() != Ongoing {
+				t.Fatalf("Game ended early in round %d at %s", round, notation)
+			}
+			m, err := board.FindMove(g.GetLegalMoves(), notation)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.RunMove(m)
+		}
+	}
+
+	if g.Repetitions() != 2 {
+		t.Errorf("Expected 2 earlier occurrences, got %d", g.Repetitions())
+	}
+	if g.GetState() != Draw {
+		t.Errorf("Expected a draw by repetition, got %d", g.GetState())
+	}
+
+	g.UndoMove()
+	if g.GetState() != Ongoing {
+		t.Errorf("Still drawn after undo")
+	}
+	g.RedoMove()
+
+	g.SetRepetitionLimit(0)
+	if g.GetState() != Ongoing {
+		t.Errorf("Drawn with repetition draws turned off")
+	}
+}
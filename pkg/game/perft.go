@@ -0,0 +1,51 @@
+package game
+
+import "github.com/ytaragin/checkers/pkg/board"
+
+// PerftResult is the number of leaf nodes below one root move
+type PerftResult struct {
+	Move  board.Move
+	Nodes uint64
+}
+
+// Perft counts the move sequences of exactly depth moves from the current
+// position. It only looks at move generation, so draws by repetition or by
+// the move limit do not end a line early.
+func Perft(g *Game, depth int) uint64 {
+	if depth <= 0 {
+		return 1
+	}
+	return perft(g.Copy(), depth)
+}
+
+// PerftDivide returns the perft count below each legal move, which helps
+// find the move where two move generators disagree
+func PerftDivide(g *Game, depth int) []PerftResult {
+	work := g.Copy()
+	results := make([]PerftResult, 0, len(work.GetLegalMoves()))
+	for _, m := range work.GetLegalMoves() {
+		work.playMove(m)
+		nodes := uint64(1)
+		if depth > 1 {
+			nodes = perft(work, depth-1)
+		}
+		work.UndoMove()
+		results = append(results, PerftResult{Move: m, Nodes: nodes})
+	}
+	return results
+}
+
+func perft(g *Game, depth int) uint64 {
+	moves := g.GetLegalMoves()
+	if depth == 1 {
+		return uint64(len(moves))
+	}
+
+	var nodes uint64
+	for _, m := range moves {
+		g.playMove(m)
+		nodes += perft(g, depth-1)
+		g.UndoMove()
+	}
+	return nodes
+}
@@ -0,0 +1,53 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/ytaragin/checkers/pkg/board"
+)
+
+// Known perft counts for American checkers from the starting position
+var americanPerft = []uint64{7, 49, 302, 1469, 7361, 36768, 179740, 845931, 3963680}
+
+func TestPerftAmerican(t *testing.T) {
+	maxDepth := len(americanPerft)
+	if testing.Short() {
+		maxDepth = 6
+	}
+
+	g := NewGame()
+	for depth := 1; depth <= maxDepth; depth++ {
+		if got := Perft(g, depth); got != americanPerft[depth-1] {
+			t.Errorf("Depth %d: got %d, want %d", depth, got, americanPerft[depth-1])
+		}
+	}
+}
+
+func TestPerftInternational(t *testing.T) {
+	expected := []uint64{9, 81, 658, 4265, 27117}
+	g := NewVariantGame(board.International)
+	for depth := 1; depth <= len(expected); depth++ {
+		if got := Perft(g, depth); got != expected[depth-1] {
+			t.Errorf("Depth %d: got %d, want %d", depth, got, expected[depth-1])
+		}
+	}
+}
+
+func TestPerftDivide(t *testing.T) {
+	g := NewGame()
+	results := PerftDivide(g, 5)
+	if len(results) != 7 {
+		t.Fatalf("Expected 7 root moves, got %d", len(results))
+	}
+
+	var total uint64
+	for _, r := range results {
+		total += r.Nodes
+	}
+	if total != americanPerft[4] {
+		t.Errorf("Divide adds up to %d, want %d", total, americanPerft[4])
+	}
+	if len(g.History()) != 0 {
+		t.Errorf("Perft changed the game")
+	}
+}
@@ -0,0 +1,201 @@
+// Package pdn reads and writes games in Portable Draughts Notation
+package pdn
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/game"
+)
+
+const (
+	ResultWhiteWin = "1-0"
+	ResultBlackWin = "0-1"
+	ResultDraw     = "1/2-1/2"
+	ResultOngoing  = "*"
+)
+
+// Tag is a single PDN header such as [Event "Casual game"]
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// Game is a game in PDN. Blue plays White and Red plays Black, since Red
+// starts on the low numbered squares.
+type Game struct {
+	Tags   []Tag
+	Moves  []board.Move
+	Result string
+}
+
+// NewGame creates a PDN game from the moves played and the state the game
+// ended in
+func NewGame(moves []board.Move, state game.GameState) *Game {
+	pg := &Game{
+		Moves:  moves,
+		Result: ResultForState(state),
+	}
+	pg.SetTag("Event", "?")
+	pg.SetTag("Date", time.Now().Format("2006.01.02"))
+	pg.SetTag("Black", "?")
+	pg.SetTag("White", "?")
+	pg.SetTag("Result", pg.Result)
+	return pg
+}
+
+// ResultForState returns the PDN result token for a game state
+func ResultForState(state game.GameState) string {
+	switch state {
+	case game.RedWin:
+		return ResultBlackWin
+	case game.BlueWin:
+		return ResultWhiteWin
+	case game.Draw:
+		return ResultDraw
+	}
+	return ResultOngoing
+}
+
+// StateForResult returns the game state a PDN result token stands for
+func StateForResult(result string) game.GameState {
+	switch result {
+	case ResultBlackWin, "0-2":
+		return game.RedWin
+	case ResultWhiteWin, "2-0":
+		return game.BlueWin
+	case ResultDraw, "1-1":
+		return game.Draw
+	}
+	return game.Ongoing
+}
+
+// GetTag returns the value of the named tag
+func (pg *Game) GetTag(name string) (string, bool) {
+	for _, t := range pg.Tags {
+		if t.Name == name {
+			return t.Value, true
+		}
+	}
+	return "", false
+}
+
+// SetTag sets the named tag, adding it if it is not there yet
+func (pg *Game) SetTag(name, value string) {
+	for i := range pg.Tags {
+		if pg.Tags[i].Name == name {
+			pg.Tags[i].Value = value
+			return
+		}
+	}
+	pg.Tags = append(pg.Tags, Tag{Name: name, Value: value})
+}
+
+// Variant returns the variant named by the GameType tag. Games without the
+// tag are American checkers.
+func (pg *Game) Variant() (*board.Variant, error) {
+	gameType, ok := pg.GetTag("GameType")
+	if !ok {
+		return board.American, nil
+	}
+	// The tag may carry extra board details after the type, as in "20,W,10,10,N2,0"
+	if i := strings.Index(gameType, ","); i >= 0 {
+		gameType = gameType[:i]
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(gameType))
+	if err != nil {
+		return nil, fmt.Errorf("invalid GameType %q", gameType)
+	}
+	return board.VariantForGameType(n)
+}
+
+// SetVariant sets the GameType tag for the variant
+func (pg *Game) SetVariant(v *board.Variant) {
+	pg.SetTag("GameType", strconv.Itoa(v.GameType))
+}
+
+// InitialGame returns the game the moves start from. This is the standard
+// starting position of the variant unless the FEN tag sets up another one.
+func (pg *Game) InitialGame() (*game.Game, error) {
+	v, err := pg.Variant()
+	if err != nil {
+		return nil, err
+	}
+	if fen, ok := pg.GetTag("FEN"); ok {
+		return game.InitVariantGameFromFEN(v, fen)
+	}
+	return game.NewVariantGame(v), nil
+}
+
+// Replay plays the moves on a new game and returns it
+func (pg *Game) Replay() (*game.Game, error) {
+	g, err := pg.InitialGame()
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range pg.Moves {
+		if g.GetState() != game.Ongoing {
+			return g, fmt.Errorf("move %d %s played after the game ended", i+1, board.MoveNotation(m))
+		}
+		legal, err := board.FindMove(g.GetLegalMoves(), board.MoveNotation(m))
+		if err != nil {
+			return g, fmt.Errorf("move %d: %w", i+1, err)
+		}
+		g.RunMove(legal)
+	}
+	return g, nil
+}
+
+// MoveText returns the moves in PDN movetext form, such as
+// "1. 11-15 23-19 2. 8-11 22-17 *". A game set up with the second player to
+// move starts with that player's move, as in "1... 23-19 2. 8-11 *".
+func (pg *Game) MoveText() string {
+	offset := 0
+	if pg.secondToMove() {
+		offset = 1
+	}
+
+	var sb strings.Builder
+	for i, m := range pg.Moves {
+		ply := i + offset
+		switch {
+		case ply%2 == 0:
+			if i > 0 {
+				sb.WriteString(" ")
+			}
+			sb.WriteString(fmt.Sprintf("%d. ", ply/2+1))
+		case i == 0:
+			sb.WriteString(fmt.Sprintf("%d... ", ply/2+1))
+		default:
+			sb.WriteString(" ")
+		}
+		sb.WriteString(board.MoveNotation(m))
+	}
+
+	result := pg.Result
+	if result == "" {
+		result = ResultOngoing
+	}
+	if sb.Len() > 0 {
+		sb.WriteString(" ")
+	}
+	sb.WriteString(result)
+	return sb.String()
+}
+
+// secondToMove reports whether the game starts with the player who does not
+// move first in the variant to move
+func (pg *Game) secondToMove() bool {
+	v, err := pg.Variant()
+	if err != nil {
+		return false
+	}
+	g, err := pg.InitialGame()
+	if err != nil {
+		return false
+	}
+	return g.NextTurn() != v.FirstTurn
+}
@@ -0,0 +1,130 @@
+package pdn
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/game"
+)
+
+func TestRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	g := game.NewGame()
+	var moves []board.Move
+	for g.GetState() == game.Ongoing {
+		legal := g.GetLegalMoves()
+		m := legal[r.Intn(len(legal))]
+		g.RunMove(m)
+		moves = append(moves, m)
+	}
+
+	pg := NewGame(moves, g.GetState())
+	pg.SetTag("Black", "Red Player")
+	pg.SetTag("White", `Blue "the" Player`)
+
+	var buf bytes.Buffer
+	if err := Write(&buf, pg); err != nil {
+		t.Fatal(err)
+	}
+
+	games, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Reading back failed: %v\n%s", err, buf.String())
+	}
+	if len(games) != 1 {
+		t.Fatalf("Expected 1 game, got %d", len(games))
+	}
+
+	read := games[0]
+	if white, _ := read.GetTag("White"); white != `Blue "the" Player` {
+		t.Errorf("White tag is %q", white)
+	}
+	if read.Result != pg.Result {
+		t.Errorf("Result %s != %s", read.Result, pg.Result)
+	}
+	if len(read.Moves) != len(moves) {
+		t.Fatalf("Read %d moves, wrote %d", len(read.Moves), len(moves))
+	}
+	for i := range moves {
+		if board.MoveNotation(read.Moves[i]) != board.MoveNotation(moves[i]) {
+			t.Errorf("Move %d: %s != %s", i, board.MoveNotation(read.Moves[i]), board.MoveNotation(moves[i]))
+		}
+	}
+
+	replayed, err := read.Replay()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.GetBoard() != g.GetBoard() || replayed.GetState() != g.GetState() {
+		t.Errorf("Replayed game does not match the original")
+	}
+}
+
+func TestReadSample(t *testing.T) {
+	sample := `[Event "Sample"]
+[Black "A"]
+[White "B"]
+[Result "*"]
+
+1. 11-15 {the usual} 23-18 2. 9-14 (2. 8-11 22-17) 18x11 3. 8x15 22-17
+4. 15-19 24x15 *
+
+[Event "Second"]
+[FEN "B:W18,21-32:B1-12"]
+1.9-14 18x9 $1 *
+`
+	games, err := ReadString(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 2 {
+		t.Fatalf("Expected 2 games, got %d", len(games))
+	}
+	if len(games[0].Moves) != 8 || len(games[1].Moves) != 2 {
+		t.Errorf("Unexpected move counts %d and %d", len(games[0].Moves), len(games[1].Moves))
+	}
+	if event, _ := games[1].GetTag("Event"); event != "Second" {
+		t.Errorf("Second game event is %q", event)
+	}
+
+	if _, err := ReadString("1. 22-18 *"); err == nil {
+		t.Errorf("Illegal move was accepted")
+	}
+}
+
+func TestMoveTextSecondToMove(t *testing.T) {
+	fen := "W:W21-32:B1-12"
+	g, err := game.InitGameFromFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var moves []board.Move
+	for _, notation := range []string{"22-18", "11-15", "18x11"} {
+		m, err := board.FindMove(g.GetLegalMoves(), notation)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.RunMove(m)
+		moves = append(moves, m)
+	}
+
+	pg := NewGame(moves, g.GetState())
+	pg.SetTag("FEN", fen)
+	if text := pg.MoveText(); text != "1... 22-18 2. 11-15 18x11 *" {
+		t.Errorf("Unexpected movetext %q", text)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, pg); err != nil {
+		t.Fatal(err)
+	}
+	games, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Reading back failed: %v\n%s", err, buf.String())
+	}
+	if len(games) != 1 || len(games[0].Moves) != len(moves) {
+		t.Errorf("Expected the %d moves back, got %v", len(moves), games)
+	}
+}
@@ -0,0 +1,236 @@
+package pdn
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/game"
+)
+
+// Read parses all the games in a PDN file. Every move is checked against the
+// legal moves of the position it is played in.
+func Read(r io.Reader) ([]*Game, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{input: []rune(string(data)), line: 1}
+	return p.parse()
+}
+
+// ReadString parses all the games in a PDN string
+func ReadString(s string) ([]*Game, error) {
+	return Read(strings.NewReader(s))
+}
+
+type parser struct {
+	input []rune
+	pos   int
+	line  int
+
+	games   []*Game
+	current *Game
+	state   *game.Game
+}
+
+func (p *parser) parse() ([]*Game, error) {
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.input) {
+			break
+		}
+
+		var err error
+		switch c := p.input[p.pos]; {
+		case c == '[':
+			err = p.parseTag()
+		case c == '{':
+			err = p.skipUntil('{', '}')
+		case c == '(':
+			err = p.skipUntil('(', ')')
+		case c == ';':
+			p.skipLine()
+		case c == '%' && p.atLineStart():
+			p.skipLine()
+		default:
+			err = p.parseToken(p.readToken())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("pdn line %d: %w", p.line, err)
+		}
+	}
+
+	if p.current != nil {
+		p.finishGame(ResultOngoing)
+	}
+	return p.games, nil
+}
+
+func (p *parser) parseTag() error {
+	if p.current != nil && len(p.current.Moves) > 0 {
+		p.finishGame(ResultOngoing)
+	}
+	p.startGame()
+
+	p.pos++
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.input) && !unicode.IsSpace(p.input[p.pos]) && p.input[p.pos] != '"' && p.input[p.pos] != ']' {
+		p.pos++
+	}
+	name := string(p.input[start:p.pos])
+	p.skipSpace()
+
+	if p.pos >= len(p.input) || p.input[p.pos] != '"' {
+		return fmt.Errorf("tag %s has no value", name)
+	}
+	p.pos++
+
+	var value strings.Builder
+	for {
+		if p.pos >= len(p.input) {
+			return fmt.Errorf("unterminated value for tag %s", name)
+		}
+		c := p.input[p.pos]
+		p.pos++
+		if c == '\\' && p.pos < len(p.input) {
+			value.WriteRune(p.input[p.pos])
+			p.pos++
+			continue
+		}
+		if c == '"' {
+			break
+		}
+		value.WriteRune(c)
+	}
+
+	p.skipSpace()
+	if p.pos >= len(p.input) || p.input[p.pos] != ']' {
+		return fmt.Errorf("unterminated tag %s", name)
+	}
+	p.pos++
+
+	p.current.SetTag(name, value.String())
+	return nil
+}
+
+func (p *parser) parseToken(token string) error {
+	if isResult(token) {
+		if p.current == nil {
+			p.startGame()
+		}
+		p.finishGame(token)
+		return nil
+	}
+
+	// Move numbers may be attached to the move, as in "1.11-15"
+	if i := strings.LastIndex(token, "."); i >= 0 {
+		token = token[i+1:]
+	}
+	token = strings.TrimRight(token, "!?")
+	if token == "" || strings.HasPrefix(token, "$") {
+		return nil
+	}
+
+	if p.current == nil {
+		p.startGame()
+	}
+	if p.state == nil {
+		g, err := p.current.InitialGame()
+		if err != nil {
+			return err
+		}
+		p.state = g
+	}
+	if p.state.GetState() != game.Ongoing {
+		return fmt.Errorf("move %s played after the game ended", token)
+	}
+	m, err := board.FindMove(p.state.GetLegalMoves(), token)
+	if err != nil {
+		return err
+	}
+	p.state.RunMove(m)
+	p.current.Moves = append(p.current.Moves, m)
+	return nil
+}
+
+func (p *parser) startGame() {
+	if p.current != nil {
+		return
+	}
+	p.current = &Game{}
+}
+
+func (p *parser) finishGame(result string) {
+	if tag, ok := p.current.GetTag("Result"); ok && result == ResultOngoing {
+		result = tag
+	}
+	p.current.Result = result
+	p.games = append(p.games, p.current)
+	p.current = nil
+	p.state = nil
+}
+
+func isResult(token string) bool {
+	switch token {
+	case ResultWhiteWin, ResultBlackWin, ResultDraw, ResultOngoing, "2-0", "0-2", "1-1", "0-0":
+		return true
+	}
+	return false
+}
+
+func (p *parser) readToken() string {
+	start := p.pos
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		if unicode.IsSpace(c) || c == '[' || c == '{' || c == '(' || c == ';' {
+			break
+		}
+		p.pos++
+	}
+	return string(p.input[start:p.pos])
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(p.input[p.pos]) {
+		if p.input[p.pos] == '\n' {
+			p.line++
+		}
+		p.pos++
+	}
+}
+
+func (p *parser) skipLine() {
+	for p.pos < len(p.input) && p.input[p.pos] != '\n' {
+		p.pos++
+	}
+}
+
+// skipUntil skips a comment or variation, which may be nested
+func (p *parser) skipUntil(open, close rune) error {
+	depth := 0
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		p.pos++
+		switch c {
+		case '\n':
+			p.line++
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("missing %q", close)
+}
+
+func (p *parser) atLineStart() bool {
+	return p.pos == 0 || p.input[p.pos-1] == '\n'
+}
@@ -0,0 +1,55 @@
+package pdn
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const lineWidth = 80
+
+// Write writes the games in PDN, separated by blank lines
+func Write(w io.Writer, games ...*Game) error {
+	for i, pg := range games {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if err := writeGame(w, pg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeGame(w io.Writer, pg *Game) error {
+	for _, t := range pg.Tags {
+		value := strings.ReplaceAll(t.Value, `\`, `\\`)
+		value = strings.ReplaceAll(value, `"`, `\"`)
+		if _, err := fmt.Fprintf(w, "[%s \"%s\"]\n", t.Name, value); err != nil {
+			return err
+		}
+	}
+	if len(pg.Tags) > 0 {
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+
+	line := ""
+	for _, token := range strings.Fields(pg.MoveText()) {
+		if line != "" && len(line)+1+len(token) > lineWidth {
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += token
+	}
+	_, err := fmt.Fprintln(w, line)
+	return err
+}
@@ -0,0 +1,112 @@
+package players
+
+import (
+	"context"
+	"time"
+)
+
+// SearchBudget is how much a Monte Carlo player searches for a move. The
+// search stops at the first limit it reaches, and limits left at zero do not
+// apply. A player given no limit at all falls back to a number of iterations
+// of its own.
+type SearchBudget struct {
+	// Iterations is the number of simulations
+	Iterations int
+	// Duration is the time spent searching
+	Duration time.Duration
+	// MaxNodes is the number of positions the simulations pass through,
+	// counting the moves made in the tree and in the playouts. Unlike a
+	// Duration it does not depend on the speed of the machine.
+	MaxNodes int
+	// ExplorationWeight is the exploration constant of the player's UCB
+	// formula. Zero means the player's default.
+	ExplorationWeight float64
+}
+
+// Unlimited returns true if none of the limits is set
+func (b SearchBudget) Unlimited() bool {
+	return b.Iterations == 0 && b.Duration == 0 && b.MaxNodes == 0
+}
+
+// withDefaultIterations returns the budget limited to the given number of
+// iterations if it has no limit
+func (b SearchBudget) withDefaultIterations(iterations int) SearchBudget {
+	if b.Unlimited() {
+		b.Iterations = iterations
+	}
+	return b
+}
+
+// forClock caps the duration of the budget by the move budget of the clock
+func (b SearchBudget) forClock(clock Clock) SearchBudget {
+	b.Duration = clock.limitDuration(b.Duration, b.Iterations+b.MaxNodes)
+	return b
+}
+
+// exploration returns the exploration constant, or def if it is not set
+func (b SearchBudget) exploration(def float64) float64 {
+	if b.ExplorationWeight == 0 {
+		return def
+	}
+	return b.ExplorationWeight
+}
+
+// share returns the part of the budget for worker w of the given number of
+// workers. The iterations and nodes are shared out while the duration applies
+// to each worker. It returns false if the worker has nothing to do.
+func (b SearchBudget) share(w, workers int) (SearchBudget, bool) {
+	split := func(n int) int {
+		s := n / workers
+		if w < n%workers {
+			s++
+		}
+		return s
+	}
+	ok := true
+	if b.Iterations > 0 {
+		b.Iterations = split(b.Iterations)
+		ok = b.Iterations > 0
+	}
+	if b.MaxNodes > 0 {
+		b.MaxNodes = split(b.MaxNodes)
+		ok = ok && b.MaxNodes > 0
+	}
+	return b, ok
+}
+
+// budgetRun keeps count of what a search has used of its budget
+type budgetRun struct {
+	ctx        context.Context
+	budget     SearchBudget
+	deadline   time.Time
+	iterations int
+	nodes      int
+	depth      int
+}
+
+// start begins a search with the budget that also stops when ctx is done
+func (b SearchBudget) start(ctx context.Context) *budgetRun {
+	return &budgetRun{ctx: ctx, budget: b, deadline: time.Now().Add(b.Duration)}
+}
+
+// add counts an iteration that passed through the given number of nodes and
+// reached the given depth of the tree
+func (r *budgetRun) add(nodes, depth int) {
+	r.iterations++
+	r.nodes += nodes
+	r.depth = max(r.depth, depth)
+}
+
+// done returns true once a limit is reached
+func (r *budgetRun) done() bool {
+	b := r.budget
+	switch {
+	case b.Iterations > 0 && r.iterations >= b.Iterations:
+		return true
+	case b.MaxNodes > 0 && r.nodes >= b.MaxNodes:
+		return true
+	case b.Duration > 0 && !time.Now().Before(r.deadline):
+		return true
+	}
+	return r.ctx.Err() != nil
+}
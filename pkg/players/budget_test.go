@@ -0,0 +1,56 @@
+package players
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/game"
+)
+
+func TestSearchBudgetLimits(t *testing.T) {
+	budgets := map[string]SearchBudget{
+		"nodes":    {MaxNodes: 3000},
+		"duration": {Duration: 20 * time.Millisecond},
+	}
+	for name, budget := range budgets {
+		searchers := map[string]Player{
+			"mc":   MCPlayer{Color: board.Red, SearchBudget: budget, Rand: NewRand(1)},
+			"rave": MCPlayerRave{Color: board.Red, SearchBudget: budget, Rand: NewRand(1)},
+			"mcst": MCSTPlayer{Color: board.Red, SelectionAlgorithm: MostVisits, SearchBudget: budget, Rand: NewRand(1)},
+		}
+		for kind, player := range searchers {
+			g := game.NewGame()
+			start := time.Now()
+			m := player.GetMove(g)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("%s with a %s budget took %s", kind, name, elapsed)
+			}
+			if _, err := board.FindMove(g.GetLegalMoves(), board.MoveNotation(m)); err != nil {
+				t.Errorf("%s with a %s budget: expected a legal move, got %s: %v",
+					kind, name, board.MoveNotation(m), err)
+			}
+		}
+	}
+}
+
+func TestSearchBudgetNodes(t *testing.T) {
+	player := MCSTPlayer{Color: board.Red, Rand: NewRand(1)}
+	root := player.newRoot(game.NewGame(), 1)
+	run := runSearch(context.Background(), root, SearchBudget{MaxNodes: 1000})
+	// Every playout from the start runs for dozens of moves
+	if run.iterations == 0 || run.iterations > 100 {
+		t.Errorf("expected a few iterations for 1000 nodes, got %d", run.iterations)
+	}
+	if run.nodes < 1000 {
+		t.Errorf("expected the search to run to 1000 nodes, got %d", run.nodes)
+	}
+
+	if _, ok := (SearchBudget{Iterations: 3}).share(3, 4); ok {
+		t.Errorf("expected the fourth of four workers to get none of 3 iterations")
+	}
+	if share, ok := (SearchBudget{Iterations: 3, Duration: time.Second}).share(0, 4); !ok || share.Iterations != 1 || share.Duration != time.Second {
+		t.Errorf("expected the first worker to get an iteration and the whole duration, got %+v", share)
+	}
+}
@@ -0,0 +1,188 @@
+package players
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/game"
+)
+
+// DefaultMovesToGo is the number of moves a clock without a move count is
+// shared out over
+const DefaultMovesToGo = 30
+
+// Clock is what a player knows about its time when asked for a move. The zero
+// Clock means the game is not timed.
+type Clock struct {
+	// Remaining is the time left on the player's clock
+	Remaining time.Duration
+	// Increment is added to the clock after every move
+	Increment time.Duration
+	// MovesToGo is the number of moves until more time is added. Zero means
+	// the remaining time has to last the rest of the game.
+	MovesToGo int
+}
+
+// Timed returns true if the player is playing against the clock
+func (c Clock) Timed() bool {
+	return c.Remaining > 0
+}
+
+// MoveBudget suggests how long to spend on the next move: an even share of
+// the remaining time plus most of the increment, but never more than half of
+// what is left. It returns zero for an untimed clock.
+func (c Clock) MoveBudget() time.Duration {
+	if !c.Timed() {
+		return 0
+	}
+	movesToGo := c.MovesToGo
+	if movesToGo <= 0 {
+		movesToGo = DefaultMovesToGo
+	}
+	budget := c.Remaining/time.Duration(movesToGo) + c.Increment*3/4
+	return min(budget, c.Remaining/2)
+}
+
+// moveContext returns a context that is done when the move budget of the
+// clock is spent
+func (c Clock) moveContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if !c.Timed() {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.MoveBudget())
+}
+
+// limitDuration caps a search duration by the move budget of the clock. An
+// untimed search with no iterations gets the whole budget.
+func (c Clock) limitDuration(d time.Duration, iterations int) time.Duration {
+	if !c.Timed() {
+		return d
+	}
+	budget := c.MoveBudget()
+	if (d == 0 && iterations == 0) || d > budget {
+		return budget
+	}
+	return d
+}
+
+func (c Clock) String() string {
+	if !c.Timed() {
+		return "untimed"
+	}
+	s := c.Remaining.Round(100 * time.Millisecond).String()
+	if c.MovesToGo > 0 {
+		s += fmt.Sprintf(" for %d moves", c.MovesToGo)
+	}
+	return s
+}
+
+// TimeControl gives each player Base time, plus Increment after every move.
+// With MovesToGo set the Base is added again every MovesToGo moves.
+type TimeControl struct {
+	Base      time.Duration
+	Increment time.Duration
+	MovesToGo int
+}
+
+// ParseTimeControl reads a time control such as "5m", "5m+3s" or "40/90m+30s"
+func ParseTimeControl(s string) (TimeControl, error) {
+	var tc TimeControl
+	rest := s
+	if moves, base, ok := strings.Cut(rest, "/"); ok {
+		n, err := strconv.Atoi(moves)
+		if err != nil || n <= 0 {
+			return tc, fmt.Errorf("invalid time control %q: bad move count", s)
+		}
+		tc.MovesToGo = n
+		rest = base
+	}
+	base, inc, hasInc := strings.Cut(rest, "+")
+	var err error
+	if tc.Base, err = time.ParseDuration(base); err != nil || tc.Base <= 0 {
+		return tc, fmt.Errorf("invalid time control %q: bad base time", s)
+	}
+	if hasInc {
+		if tc.Increment, err = time.ParseDuration(inc); err != nil || tc.Increment < 0 {
+			return tc, fmt.Errorf("invalid time control %q: bad increment", s)
+		}
+	}
+	return tc, nil
+}
+
+func (tc TimeControl) String() string {
+	s := tc.Base.String()
+	if tc.MovesToGo > 0 {
+		s = fmt.Sprintf("%d/%s", tc.MovesToGo, s)
+	}
+	if tc.Increment > 0 {
+		s += "+" + tc.Increment.String()
+	}
+	return s
+}
+
+// NewClock returns a player's clock at the start of the game
+func (tc TimeControl) NewClock() Clock {
+	return Clock{Remaining: tc.Base, Increment: tc.Increment, MovesToGo: tc.MovesToGo}
+}
+
+// Punch updates the clock after a move that took elapsed. It returns false if
+// the player ran out of time.
+func (tc TimeControl) Punch(c *Clock, elapsed time.Duration) bool {
+	c.Remaining -= elapsed
+	if c.Remaining <= 0 {
+		c.Remaining = 0
+		return false
+	}
+	c.Remaining += tc.Increment
+	if tc.MovesToGo > 0 {
+		c.MovesToGo--
+		if c.MovesToGo == 0 {
+			c.Remaining += tc.Base
+			c.MovesToGo = tc.MovesToGo
+		}
+	}
+	return true
+}
+
+// ContextPlayer is a player that can be told how much time it has and be
+// interrupted. It should return its best move so far when ctx is done.
+type ContextPlayer interface {
+	GetMoveContext(ctx context.Context, g *game.Game, clock Clock) board.Move
+}
+
+// AdaptPlayer returns the player as a ContextPlayer. Players that do not
+// implement it are run on their own goroutine and abandoned, returning nil,
+// if ctx is done first.
+func AdaptPlayer(p Player) ContextPlayer {
+	if cp, ok := p.(ContextPlayer); ok {
+		return cp
+	}
+	return playerAdapter{p}
+}
+
+type playerAdapter struct {
+	Player
+}
+
+func (a playerAdapter) GetMoveContext(ctx context.Context, g *game.Game, clock Clock) board.Move {
+	if ctx.Done() == nil {
+		return a.GetMove(g)
+	}
+
+	// The player gets its own copy so an abandoned search cannot touch g
+	result := make(chan board.Move, 1)
+	work := g.Copy()
+	go func() {
+		result <- a.GetMove(work)
+	}()
+	select {
+	case m := <-result:
+		return m
+	case <-ctx.Done():
+		return nil
+	}
+}
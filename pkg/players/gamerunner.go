@@ -1,7 +1,11 @@
 package players
 
 import (
+	"context"
 	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
 	"time"
 
 	"github.com/ytaragin/checkers/pkg/board"
@@ -11,41 +15,129 @@ import (
 type GameRunner struct {
 	players map[board.PieceColor]Player
 	game    *game.Game
+	rand    *rand.Rand
+
+	timeControl *TimeControl
+	clocks      map[board.PieceColor]Clock
+	timedOut    bool
 }
 
-func RunMultiple(redPlayer Player, bluePlayer Player, amount int, printPerStep bool) {
-	redWins := 0
-	blueWins := 0
-	draws := 0
+// MatchOptions controls how RunMultiple plays its games
+type MatchOptions struct {
+	// Games is the number of games to play
+	Games int
+	// Workers is the number of games played at the same time. Zero uses one
+	// worker per CPU. Printing every move forces a single worker.
+	Workers int
+	// Seed is the seed of the first game. Game i gets Seed+i, so a match can
+	// be replayed game by game.
+	Seed         int64
+	PrintPerStep bool
+	// RandomOpening is the number of random moves played at the start of
+	// every game, which varies the games between deterministic players
+	RandomOpening int
+	// Variant is the rules the games are played by. Nil means American.
+	Variant *board.Variant
+	// TimeControl is the time each player gets for a game. The zero value
+	// means the games are not timed.
+	TimeControl TimeControl
+}
 
-	for i := 0; i < amount; i++ {
+// MatchResult counts the outcomes of the games of a match
+type MatchResult struct {
+	RedWins, BlueWins, Draws int
+	// Moves is the total number of moves played in all the games
+	Moves int
+	// TimeForfeits is the number of games lost on time
+	TimeForfeits int
+}
 
-		g := game.NewGame()
-		// g.Dump()
+// Games returns the number of games counted
+func (mr MatchResult) Games() int {
+	return mr.RedWins + mr.BlueWins + mr.Draws
+}
 
-		runner := RunGame(g, redPlayer, bluePlayer)
-		runner.RunTillEnd(printPerStep)
+func (mr *MatchResult) add(g *game.Game) {
+	switch g.GetState() {
+	case game.Draw:
+		mr.Draws++
+	case game.RedWin:
+		mr.RedWins++
+	case game.BlueWin:
+		mr.BlueWins++
+	}
+	mr.Moves += g.MoveCount()
+}
 
-		switch g.GetState() {
-		case game.Draw:
-			draws++
-		case game.RedWin:
-			redWins++
-		case game.BlueWin:
-			blueWins++
-		}
+func (mr MatchResult) String() string {
+	return fmt.Sprintf("Red Wins: %d Blue Wins: %d Draws: %d", mr.RedWins, mr.BlueWins, mr.Draws)
+}
 
-		if i%100 == 0 {
-			println("+")
-		} else if i%10 == 0 {
-			print("#")
-			fmt.Printf("Red Wins: %d Blue Wins: %d Draws: %d\n", redWins, blueWins, draws)
-		}
+// RunMultiple plays a match between the players made by the factories. The
+// games are independent, so they are shared out between a pool of workers.
+// Every game gets new players created with the game's seed.
+func RunMultiple(redPlayer, bluePlayer PlayerFactory, opts MatchOptions) MatchResult {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if opts.PrintPerStep {
+		workers = 1
+	}
+	variant := opts.Variant
+	if variant == nil {
+		variant = board.American
+	}
+
+	var (
+		mu     sync.Mutex
+		result MatchResult
+		wg     sync.WaitGroup
+	)
+	gameNumbers := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range gameNumbers {
+				seed := opts.Seed + int64(i)
+				redSeed, blueSeed := PlayerSeeds(seed)
+				g := game.NewVariantGame(variant)
+				runner := RunGame(g, redPlayer(board.Red, redSeed), bluePlayer(board.Blue, blueSeed))
+				runner.SetRand(NewRand(seed))
+				runner.PlayRandomOpening(opts.RandomOpening)
+				if opts.TimeControl.Base > 0 {
+					runner.SetTimeControl(opts.TimeControl)
+				}
+				if opts.PrintPerStep {
+					runner.RunTillEnd(true)
+				} else {
+					runner.Play()
+				}
 
+				mu.Lock()
+				result.add(g)
+				if runner.TimedOut() {
+					result.TimeForfeits++
+				}
+				if n := result.Games(); n%100 == 0 {
+					fmt.Printf("+ %d %s\n", n, result)
+				} else if n%10 == 0 {
+					fmt.Printf("# %d %s\n", n, result)
+				}
+				mu.Unlock()
+			}
+		}()
 	}
 
-	fmt.Printf("Red Wins: %d Blue Wins: %d Draws: %d\n", redWins, blueWins, draws)
+	for i := 0; i < opts.Games; i++ {
+		gameNumbers <- i
+	}
+	close(gameNumbers)
+	wg.Wait()
 
+	fmt.Println(result)
+	return result
 }
 
 func RunGame(game *game.Game, redPlayer Player, bluePlayer Player) *GameRunner {
@@ -54,15 +146,120 @@ func RunGame(game *game.Game, redPlayer Player, bluePlayer Player) *GameRunner {
 	players[board.Red] = redPlayer
 	players[board.Blue] = bluePlayer
 
-	runner := &GameRunner{players, game}
+	runner := &GameRunner{players: players, game: game}
 	return runner
 }
 
+// SetRand sets the source of the runner's random choices. Nil uses the
+// global source.
+func (gr *GameRunner) SetRand(r *rand.Rand) {
+	gr.rand = r
+}
+
+// PlayRandomOpening plays up to plies random moves for both sides
+func (gr *GameRunner) PlayRandomOpening(plies int) {
+	for i := 0; i < plies && gr.game.GetState() == game.Ongoing; i++ {
+		moves := gr.game.GetLegalMoves()
+		gr.game.RunMove(moves[randIntn(gr.rand, len(moves))])
+	}
+}
+
+// Moves returns the moves played so far
+func (gr *GameRunner) Moves() []board.Move {
+	return gr.game.History()
+}
+
+// PlayMove asks the side to move for a move and plays it. It returns nil if
+// the player resigned.
+func (gr *GameRunner) PlayMove() board.Move {
+	player := gr.players[gr.game.NextTurn()]
+	if p, ok := player.(Ponderer); ok {
+		p.StopPondering()
+	}
+
+	var m board.Move
+	if gr.timeControl == nil {
+		m = player.GetMove(gr.game)
+	} else {
+		m = gr.timedMove(player)
+	}
+	if m == nil {
+		gr.game.Resign()
+	} else {
+		gr.game.RunMove(m)
+	}
+
+	if gr.game.GetState() != game.Ongoing {
+		gr.StopPondering()
+	} else if p, ok := player.(Ponderer); ok {
+		p.StartPondering(gr.game.Copy())
+	}
+	return m
+}
+
+// timedMove asks the player for a move against its clock. It returns nil if
+// the player ran out of time.
+func (gr *GameRunner) timedMove(player Player) board.Move {
+	turn := gr.game.NextTurn()
+	clock := gr.clocks[turn]
+	ctx, cancel := context.WithTimeout(context.Background(), clock.Remaining)
+	defer cancel()
+
+	start := time.Now()
+	m := AdaptPlayer(player).GetMoveContext(ctx, gr.game, clock)
+	if !gr.timeControl.Punch(&clock, time.Since(start)) {
+		gr.timedOut = true
+		m = nil
+	}
+	gr.clocks[turn] = clock
+	return m
+}
+
+// SetTimeControl starts both players' clocks. A player who runs out of time
+// forfeits the game.
+func (gr *GameRunner) SetTimeControl(tc TimeControl) {
+	gr.timeControl = &tc
+	gr.clocks = map[board.PieceColor]Clock{
+		board.Red:  tc.NewClock(),
+		board.Blue: tc.NewClock(),
+	}
+}
+
+// Clock returns the clock of the color. It is the zero Clock if the game is
+// not timed.
+func (gr *GameRunner) Clock(color board.PieceColor) Clock {
+	return gr.clocks[color]
+}
+
+// TimedOut returns true if the game was lost on time
+func (gr *GameRunner) TimedOut() bool {
+	return gr.timedOut
+}
+
+// StopPondering stops any player thinking on its opponent's time
+func (gr *GameRunner) StopPondering() {
+	for _, player := range gr.players {
+		if p, ok := player.(Ponderer); ok {
+			p.StopPondering()
+		}
+	}
+}
+
+// Play runs the game to the end without printing anything
+func (gr *GameRunner) Play() game.GameState {
+	for gr.game.GetState() == game.Ongoing {
+		gr.PlayMove()
+	}
+	return gr.game.GetState()
+}
+
 func (gr *GameRunner) RunTillEnd(printPerStep bool) {
 	start := time.Now()
 	for gr.game.GetState() == game.Ongoing {
-		m := gr.players[gr.game.NextTurn()].GetMove(gr.game)
-		gr.game.RunMove(m)
+		m := gr.PlayMove()
+		if m == nil {
+			continue
+		}
 
 		i := gr.game.MoveCount()
 		if i%10 == 0 {
@@ -71,6 +268,9 @@ func (gr *GameRunner) RunTillEnd(printPerStep bool) {
 		if printPerStep {
 			fmt.Println(m)
 			gr.game.Dump()
+			if gr.timeControl != nil {
+				fmt.Printf("Red: %s Blue: %s\n", gr.Clock(board.Red), gr.Clock(board.Blue))
+			}
 		}
 
 	}
@@ -79,6 +279,9 @@ func (gr *GameRunner) RunTillEnd(printPerStep bool) {
 	shifted := (float64(elapsed) / 1e6)
 	timePerMove := shifted / float64(gr.game.MoveCount())
 	fmt.Printf("Winner: %s Moves: %d Time: %2f Time Per Move: %2f\n", gr.game.GetWinner().Name(), gr.game.MoveCount(), shifted, timePerMove)
+	if gr.timedOut {
+		fmt.Printf("%s lost on time\n", gr.game.NextTurn().Name())
+	}
 
 	// switch gr.game.GetState() {
 	// case game.RedWin:
@@ -0,0 +1,114 @@
+package players
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/game"
+)
+
+func TestRunMultipleWorkers(t *testing.T) {
+	random := func(color board.PieceColor, seed int64) Player {
+		return RandomPlayer{Color: color}
+	}
+
+	result := RunMultiple(random, random, MatchOptions{Games: 25, Workers: 4})
+	if result.Games() != 25 {
+		t.Errorf("expected 25 games, got %d (%s)", result.Games(), result)
+	}
+	if result.Moves == 0 {
+		t.Errorf("expected moves to be counted")
+	}
+}
+
+func TestRunMultipleSeeds(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[int64]int{}
+	factory := func(color board.PieceColor, seed int64) Player {
+		mu.Lock()
+		seen[seed]++
+		mu.Unlock()
+		return RandomPlayer{Color: color, Rand: NewRand(seed)}
+	}
+
+	RunMultiple(factory, factory, MatchOptions{Games: 10, Workers: 2, Seed: 7})
+	if len(seen) != 20 {
+		t.Errorf("expected a seed for each player of each game, got %v", seen)
+	}
+}
+
+func TestSeededGamesRepeat(t *testing.T) {
+	play := func(seed int64) []board.Move {
+		g := game.NewGame()
+		runner := RunGame(g,
+			MCSTPlayer{Color: board.Red, SelectionAlgorithm: MostVisits, SearchBudget: SearchBudget{Iterations: 200}, Rand: NewRand(seed)},
+			MCPlayer{Color: board.Blue, SearchBudget: SearchBudget{Iterations: 5}, Rand: NewRand(seed + 1)})
+		runner.SetRand(NewRand(seed))
+		runner.PlayRandomOpening(4)
+		runner.Play()
+		return g.History()
+	}
+
+	first, second := play(42), play(42)
+	if len(first) != len(second) {
+		t.Fatalf("expected the same game twice, got %d and %d moves", len(first), len(second))
+	}
+	for i := range first {
+		if board.MoveNotation(first[i]) != board.MoveNotation(second[i]) {
+			t.Fatalf("games differ at move %d: %s and %s", i+1,
+				board.MoveNotation(first[i]), board.MoveNotation(second[i]))
+		}
+	}
+}
+
+// slowPlayer plays randomly after a pause
+type slowPlayer struct {
+	RandomPlayer
+	delay time.Duration
+}
+
+func (s slowPlayer) GetMove(g *game.Game) board.Move {
+	time.Sleep(s.delay)
+	return s.RandomPlayer.GetMove(g)
+}
+
+func TestTimeForfeit(t *testing.T) {
+	g := game.NewGame()
+	runner := RunGame(g,
+		slowPlayer{RandomPlayer{Color: board.Red}, time.Second},
+		RandomPlayer{Color: board.Blue})
+	runner.SetTimeControl(TimeControl{Base: 50 * time.Millisecond})
+
+	if state := runner.Play(); state != game.BlueWin || !runner.TimedOut() {
+		t.Errorf("expected Red to lose on time, got %v timed out %v", state, runner.TimedOut())
+	}
+	if g.MoveCount() != 0 {
+		t.Errorf("expected no moves to be played, got %d", g.MoveCount())
+	}
+}
+
+func TestTimeControl(t *testing.T) {
+	tc, err := ParseTimeControl("40/90m+30s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc != (TimeControl{Base: 90 * time.Minute, Increment: 30 * time.Second, MovesToGo: 40}) {
+		t.Errorf("unexpected time control %+v", tc)
+	}
+	if _, err := ParseTimeControl("0/5m"); err == nil {
+		t.Errorf("expected an error for a zero move count")
+	}
+
+	tc = TimeControl{Base: time.Minute, MovesToGo: 2}
+	clock := tc.NewClock()
+	tc.Punch(&clock, 10*time.Second)
+	tc.Punch(&clock, 10*time.Second)
+	if clock.Remaining != 100*time.Second || clock.MovesToGo != 2 {
+		t.Errorf("expected the base time to be added after 2 moves, got %+v", clock)
+	}
+	if tc.Punch(&clock, 2*time.Minute) {
+		t.Errorf("expected the clock to run out")
+	}
+}
@@ -0,0 +1,151 @@
+package players
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/game"
+)
+
+// HumanPlayer lets a person play from the terminal. Moves are entered by
+// their number in the list of legal moves or in standard notation, such as
+// "11-15" or "22x15x8".
+type HumanPlayer struct {
+	Color board.PieceColor
+	// Hint suggests a move when asked. A shallow MinimaxPlayer is used if
+	// it is not set.
+	Hint Player
+
+	in  *bufio.Scanner
+	out io.Writer
+}
+
+// NewHumanPlayer creates a player reading moves from in and writing prompts
+// to out. Nil arguments default to the standard input and output.
+func NewHumanPlayer(color board.PieceColor, in io.Reader, out io.Writer) *HumanPlayer {
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stdout
+	}
+	return &HumanPlayer{
+		Color: color,
+		in:    bufio.NewScanner(in),
+		out:   out,
+	}
+}
+
+const humanHelp = `Enter a move by its number or in notation, such as 11-15 or 22x15x8.
+Commands:
+  moves   list the legal moves
+  board   show the board
+  undo    take back your last move
+  hint    suggest a move
+  resign  give up the game
+  help    show this help
+`
+
+func (h *HumanPlayer) GetMove(g *game.Game) board.Move {
+	if h.in == nil {
+		*h = *NewHumanPlayer(h.Color, nil, nil)
+	}
+
+	h.show(g)
+	for {
+		fmt.Fprintf(h.out, "%s to move> ", g.NextTurn().Name())
+		if !h.in.Scan() {
+			fmt.Fprintln(h.out, "No more input, resigning")
+			return nil
+		}
+
+		input := strings.TrimSpace(h.in.Text())
+		moves := g.GetLegalMoves()
+
+		switch strings.ToLower(input) {
+		case "":
+			continue
+		case "help", "?":
+			fmt.Fprint(h.out, humanHelp)
+		case "moves", "list":
+			h.listMoves(moves)
+		case "board":
+			h.show(g)
+		case "undo":
+			h.undo(g)
+		case "hint":
+			h.hint(g)
+		case "resign", "quit":
+			return nil
+		default:
+			m, err := parseHumanMove(moves, input)
+			if err != nil {
+				fmt.Fprintf(h.out, "%s. Type help for help.\n", err)
+				continue
+			}
+			return m
+		}
+	}
+}
+
+// parseHumanMove accepts the number of a move in the list or its notation
+func parseHumanMove(moves []board.Move, input string) (board.Move, error) {
+	if n, err := strconv.Atoi(input); err == nil {
+		if n < 1 || n > len(moves) {
+			return nil, fmt.Errorf("choose a move between 1 and %d", len(moves))
+		}
+		return moves[n-1], nil
+	}
+	return board.FindMove(moves, input)
+}
+
+// GetMoveContext shows the time left before asking for a move. Reading the
+// terminal cannot be interrupted, so the move is taken whenever it comes and
+// the GameRunner decides if it was in time.
+func (h *HumanPlayer) GetMoveContext(ctx context.Context, g *game.Game, clock Clock) board.Move {
+	if h.in == nil {
+		*h = *NewHumanPlayer(h.Color, nil, nil)
+	}
+	if clock.Timed() {
+		fmt.Fprintf(h.out, "Time left: %s\n", clock)
+	}
+	return h.GetMove(g)
+}
+
+func (h *HumanPlayer) show(g *game.Game) {
+	g.DumpTo(h.out)
+	h.listMoves(g.GetLegalMoves())
+}
+
+func (h *HumanPlayer) listMoves(moves []board.Move) {
+	for i, m := range moves {
+		fmt.Fprintf(h.out, "%2d) %s\n", i+1, board.MoveNotation(m))
+	}
+}
+
+// undo takes back the opponent's last move and the player's move before it,
+// so it is the player's turn again
+func (h *HumanPlayer) undo(g *game.Game) {
+	if len(g.History()) < 2 {
+		fmt.Fprintln(h.out, "Nothing to undo")
+		return
+	}
+	g.UndoMove()
+	g.UndoMove()
+	h.show(g)
+}
+
+func (h *HumanPlayer) hint(g *game.Game) {
+	hinter := h.Hint
+	if hinter == nil {
+		hinter = MinimaxPlayer{Color: g.NextTurn(), MaxDepth: 6}
+	}
+	m := hinter.GetMove(g.Copy())
+	fmt.Fprintf(h.out, "Hint: %s\n", board.MoveNotation(m))
+}
@@ -0,0 +1,54 @@
+package players
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/game"
+)
+
+func TestHumanPlayerInput(t *testing.T) {
+	g := game.NewGame()
+	in := strings.NewReader("bogus\n99\nundo\n2\n11-15\nresign\n")
+	h := NewHumanPlayer(board.Red, in, io.Discard)
+
+	if m := h.GetMove(g); m != g.GetLegalMoves()[1] {
+		t.Errorf("Expected the second move, got %v", m)
+	}
+	if m := h.GetMove(g); board.MoveNotation(m) != "11-15" {
+		t.Errorf("Expected 11-15, got %v", m)
+	}
+	if m := h.GetMove(g); m != nil {
+		t.Errorf("Expected resignation, got %v", m)
+	}
+	if m := h.GetMove(g); m != nil {
+		t.Errorf("Expected resignation at end of input, got %v", m)
+	}
+}
+
+func TestHumanPlayerUndo(t *testing.T) {
+	g := game.NewGame()
+	g.RunMove(g.GetLegalMoves()[0])
+	g.RunMove(g.GetLegalMoves()[0])
+
+	h := NewHumanPlayer(board.Red, strings.NewReader("undo\n1\n"), io.Discard)
+	h.GetMove(g)
+	if len(g.History()) != 0 {
+		t.Errorf("Expected both moves taken back, %d left", len(g.History()))
+	}
+}
+
+func TestHumanPlayerShowsBoard(t *testing.T) {
+	g := game.NewGame()
+	var out strings.Builder
+	h := NewHumanPlayer(board.Red, strings.NewReader("board\nresign\n"), &out)
+	h.GetMove(g)
+
+	var want strings.Builder
+	g.DumpTo(&want)
+	if !strings.Contains(out.String(), want.String()) {
+		t.Errorf("expected the board in the output, got\n%s", out.String())
+	}
+}
@@ -1,12 +1,16 @@
 package players
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"math/rand"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/eval"
 	"github.com/ytaragin/checkers/pkg/game"
 )
 
@@ -41,105 +45,323 @@ var (
 type MCSTPlayer struct {
 	Color              board.PieceColor
 	SelectionAlgorithm ChildStatSelecter
-	Iterations         int
-	Duration           time.Duration
-	Verbose            bool
+	// SearchBudget limits the search of each move. Without a limit the
+	// player runs 50000 iterations. Its ExplorationWeight defaults to
+	// sqrt(2).
+	SearchBudget
+	Verbose bool
+	// TableSize enables a transposition table of that many entries so that
+	// positions reached by different move orders share a single node
+	TableSize int
+	// Rand is the source of the random playouts. Nil uses the global source.
+	// A player with its own source must not be used by several goroutines.
+	Rand *rand.Rand
+	// Workers is the number of searches run at the same time. Each worker
+	// builds its own tree and the statistics of the root moves are added up
+	// at the end. Iterations and nodes are shared out between the workers
+	// while a Duration applies to each of them. Zero means one worker.
+	Workers int
+	// Playout chooses the moves of the simulated games. Nil means
+	// RandomPlayout.
+	Playout PlayoutPolicy
+	// PlayoutDepth stops a simulated game after that many moves and scores
+	// it by its evaluation. Zero plays every game to the end.
+	PlayoutDepth int
+	// Weights score the simulated games that are stopped early. Nil means
+	// eval.DefaultWeights.
+	Weights *eval.Weights
+	// TreePolicy chooses the child to descend into during selection. Nil
+	// means UCB1.
+	TreePolicy TreePolicy
+}
+
+// DefaultExploration is the exploration constant of UCB1
+var DefaultExploration = math.Sqrt2
+
+// mcstSearch holds the state shared by all the nodes of one search
+type mcstSearch struct {
+	table        *TranspositionTable[*MCSTNode]
+	exploration  float64
+	policy       TreePolicy
+	rand         *rand.Rand
+	playout      PlayoutPolicy
+	playoutDepth int
+	weights      eval.Weights
 }
 
 func (mc MCSTPlayer) GetMove(g *game.Game) board.Move {
+	return mc.GetMoveContext(context.Background(), g, Clock{})
+}
+
+// GetMoveContext searches until the search budget is used up, the move
+// budget of the clock is spent or ctx is done
+func (mc MCSTPlayer) GetMoveContext(ctx context.Context, g *game.Game, clock Clock) board.Move {
+	return mc.Search(ctx, g, clock).Move
+}
+
+// Search is GetMoveContext that reports on the search
+func (mc MCSTPlayer) Search(ctx context.Context, g *game.Game, clock Clock) SearchInfo {
+	info, _ := mc.SearchTree(ctx, g, clock)
+	return info
+}
+
+// SearchTree is Search that also returns the searched trees, one for each
+// worker. A position with a single legal move is not searched and has no
+// trees.
+func (mc MCSTPlayer) SearchTree(ctx context.Context, g *game.Game, clock Clock) (SearchInfo, []*MCSTNode) {
 
 	moves := g.GetLegalMoves()
 	if len(moves) == 1 {
-		return moves[0]
+		return onlyMove(moves[0]), nil
 	}
 
-	if mc.Duration == 0 && mc.Iterations == 0 {
-		mc.Iterations = 50000
-		fmt.Printf("No Iterations or Duration set. Will run %d iterations", mc.Iterations)
-	}
+	mc.SearchBudget = mc.SearchBudget.forClock(clock)
+	ctx, cancel := clock.moveContext(ctx)
+	defer cancel()
 
 	// iterations := 50000
 	// bestMove := mc.GetBestMove(g, iterations)
-	bestMove := mc.GetBestMove(g)
+	mc = mc.withDefaultBudget()
+	roots := make([]*MCSTNode, max(mc.Workers, 1))
+	for w := range roots {
+		roots[w] = mc.newRoot(g, len(roots))
+	}
+	return mc.searchRoots(ctx, roots), roots
+}
 
-	return bestMove
+// withDefaultBudget returns the player with a number of iterations set if
+// its budget has no limit
+func (mc MCSTPlayer) withDefaultBudget() MCSTPlayer {
+	if mc.Unlimited() {
+		mc.SearchBudget = mc.withDefaultIterations(50000)
+		fmt.Printf("No Iterations, Duration or MaxNodes set. Will run %d iterations", mc.Iterations)
+	}
+	return mc
 }
 
 // func (mc MCSTPlayer) GetBestMove(g *game.Game, iterations int, d time.Duration) board.Move {
 func (mc MCSTPlayer) GetBestMove(g *game.Game) board.Move {
-	rootNode := &MCSTNode{
-		State: g,
+	mc = mc.withDefaultBudget()
+	roots := make([]*MCSTNode, max(mc.Workers, 1))
+	for w := range roots {
+		roots[w] = mc.newRoot(g, len(roots))
+	}
+	return mc.searchRoots(context.Background(), roots).Move
+}
+
+// newRoot creates the root of a new tree for the position, to be searched by
+// one of the given number of workers
+func (mc MCSTPlayer) newRoot(g *game.Game, workers int) *MCSTNode {
+	search := &mcstSearch{
+		exploration:  mc.exploration(DefaultExploration),
+		policy:       mc.TreePolicy,
+		rand:         mc.Rand,
+		playout:      mc.Playout,
+		playoutDepth: mc.PlayoutDepth,
+		weights:      eval.DefaultWeights,
+	}
+	if mc.Weights != nil {
+		search.weights = *mc.Weights
+	}
+	if search.policy == nil {
+		search.policy = UCB1
+	}
+	if mc.TableSize > 0 {
+		search.table = NewTranspositionTable[*MCSTNode](mc.TableSize)
+	}
+	if workers > 1 && mc.Rand != nil {
+		// A rand.Rand is not safe for concurrent use, so each worker gets
+		// its own source seeded from the player's
+		search.rand = NewRand(mc.Rand.Int63())
+	}
+	return &MCSTNode{
+		State: g.Copy(),
 		// Player: node.player,
-		Move:     nil,
 		Parent:   nil,
 		Children: nil,
+		search:   search,
 	}
-	count := 0
+}
 
-	if mc.Iterations > 0 {
-		for i := 0; i < mc.Iterations; i++ {
-			rootNode.RunLoop()
+// searchRoots searches each root on its own goroutine and returns the best
+// move according to the combined statistics of the roots' children
+func (mc MCSTPlayer) searchRoots(ctx context.Context, roots []*MCSTNode) SearchInfo {
+	start := time.Now()
+	workers := len(roots)
+	runs := make([]*budgetRun, workers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		budget, ok := mc.share(w, workers)
+		if !ok {
+			// Make sure the root has children even if a worker has no
+			// iterations
+			roots[w].Expand()
+			continue
 		}
-		count = mc.Iterations
-	} else {
-		endTime := time.Now().Add(mc.Duration)
-		for time.Now().Before(endTime) {
-			rootNode.RunLoop()
-			count++
+
+		wg.Add(1)
+		go func(w int, budget SearchBudget) {
+			defer wg.Done()
+			runs[w] = runSearch(ctx, roots[w], budget)
+		}(w, budget)
+	}
+	wg.Wait()
+
+	children := mergeRootChildren(roots)
+	moves := roots[0].ChildMoves
+	best := 0
+	for i, child := range children {
+		if mc.SelectionAlgorithm.SelectStat(child) > mc.SelectionAlgorithm.SelectStat(children[best]) {
+			best = i
 		}
 	}
 
-	bestChild := rootNode.Children[0]
-	for _, child := range rootNode.Children {
-
-		// if mc.Verbose {
-		// 	fmt.Printf("%s %.2f %d\n", child.Move, child.WinCount, child.VisitCount)
-		// }
-		if mc.SelectionAlgorithm.SelectStat(child) > mc.SelectionAlgorithm.SelectStat(bestChild) {
-			bestChild = child
+	info := SearchInfo{
+		Move:     moves[best],
+		Duration: time.Since(start),
+		PV:       []board.Move{moves[best]},
+	}
+	for _, run := range runs {
+		if run != nil {
+			info.Iterations += run.iterations
+			info.Nodes += run.nodes
+			info.Depth = max(info.Depth, run.depth)
 		}
 	}
+	for i, child := range children {
+		info.Moves = append(info.Moves, MoveStats{Move: moves[i], Visits: child.VisitCount, Value: child.value()})
+	}
+	// The rest of the line is followed in the tree that searched the move
+	// the most
+	deepest := roots[0].Children[best]
+	for _, root := range roots {
+		if root.Children[best].VisitCount > deepest.VisitCount {
+			deepest = root.Children[best]
+		}
+	}
+	info.PV = append(info.PV, deepest.principalVariation()...)
+
 	if mc.Verbose {
-		fmt.Printf("Iterations: %d, Visits: %d WinCount: %d %s: %.4f\n",
-			count,
-			bestChild.VisitCount,
-			bestChild.WinCount,
-			mc.SelectionAlgorithm.StatName(),
-			mc.SelectionAlgorithm.SelectStat(bestChild))
+		fmt.Printf("Workers: %d %s: %.4f\n", workers,
+			mc.SelectionAlgorithm.StatName(), mc.SelectionAlgorithm.SelectStat(children[best]))
+		info.Print(os.Stdout)
+		if mc.TableSize > 0 {
+			hits, misses := 0, 0
+			for _, root := range roots {
+				h, m := root.search.table.Stats()
+				hits += h
+				misses += m
+			}
+			fmt.Printf("Table hits: %d misses: %d\n", hits, misses)
+		}
+	}
+	return info
+}
+
+// runSearch grows the tree under the root until the budget is used up or ctx
+// is done
+func runSearch(ctx context.Context, rootNode *MCSTNode, budget SearchBudget) *budgetRun {
+	run := budget.start(ctx)
+	for !run.done() {
+		run.add(rootNode.runLoop())
+	}
+	// Make sure the root has children even if the search was stopped at once
+	rootNode.Expand()
+	return run
+}
+
+// mergeRootChildren adds up the statistics of the root moves of independent
+// searches of the same position. Every root has its children in legal move
+// order, so they are matched by index.
+func mergeRootChildren(roots []*MCSTNode) []*MCSTNode {
+	if len(roots) == 1 {
+		return roots[0].Children
+	}
+	merged := make([]*MCSTNode, len(roots[0].Children))
+	for i, child := range roots[0].Children {
+		merged[i] = &MCSTNode{
+			State:  child.State,
+			Parent: roots[0],
+		}
+		for _, root := range roots {
+			merged[i].VisitCount += root.Children[i].VisitCount
+			merged[i].WinCount += root.Children[i].WinCount
+		}
 	}
-	return bestChild.Move
+	return merged
 }
 
 type MCSTNode struct {
 	State      *game.Game
-	Move       board.Move
 	VisitCount int
 	WinCount   float64
+	// WinSquares is the sum of the squares of the results, for the variance
+	WinSquares float64
 	Children   []*MCSTNode
-	Parent     *MCSTNode
+	// ChildMoves are the moves leading to the Children, in the same order.
+	// The moves belong to the edges rather than the children, since with a
+	// transposition table a child can be reached from several parents by
+	// different moves.
+	ChildMoves []board.Move
+	// ChildPriors and ChildHeuristics are set on the edges by tree policies
+	// that use knowledge about the moves, in the order of the Children
+	ChildPriors     []float64
+	ChildHeuristics []float64
+	// Parent is the node that first created this one. With a transposition
+	// table a node can be reached from several parents.
+	Parent *MCSTNode
+	search *mcstSearch
 }
 
 func (node *MCSTNode) RunLoop() {
+	node.runLoop()
+}
+
+// runLoop runs one iteration and returns the number of positions it passed
+// through and the depth it reached in the tree
+func (node *MCSTNode) runLoop() (int, int) {
 	if node.State.GetState() != game.Ongoing {
 		node.BackPropagate(node.State.GetState())
-		return
+		return 1, 0
 	}
-	child := node.Select()
+	path := node.selectPath()
+	child := path[len(path)-1]
+
+	result, plies := child.simulate()
+	backPropagatePath(path, result)
+	return len(path) + plies, len(path) - 1
+}
 
-	endingStatus := child.Simulate()
-	child.BackPropagate(endingStatus)
+// Simulate plays the game out from the node with the search's playout
+// policy. With a playout depth set, a game still going after that many moves
+// is scored by its evaluation instead.
+func (node *MCSTNode) Simulate() PlayoutResult {
+	result, _ := node.simulate()
+	return result
 }
 
-func (node *MCSTNode) Simulate() game.GameState {
+// simulate is Simulate that also returns the number of moves played
+func (node *MCSTNode) simulate() (PlayoutResult, int) {
+	search := node.search
+	if search == nil {
+		search = &mcstSearch{}
+	}
+	policy := search.playout
+	if policy == nil {
+		policy = RandomPlayout{}
+	}
+
 	tempGame := node.State.Copy()
-	for tempGame.GetState() == game.Ongoing {
-		moves := tempGame.GetLegalMoves()
-		randomIndex := rand.Intn(len(moves))
-		randomMove := moves[randomIndex]
-		tempGame.RunMove(randomMove)
+	depth := 0
+	for ; tempGame.GetState() == game.Ongoing; depth++ {
+		if search.playoutDepth > 0 && depth >= search.playoutDepth {
+			return evaluatedResult(tempGame, search.weights), depth
+		}
+		tempGame.RunMove(policy.ChooseMove(tempGame, search.rand))
 	}
 
-	return tempGame.GetState()
+	return ResultForState(tempGame.GetState()), depth
 }
 
 func (node *MCSTNode) Expand() bool {
@@ -148,73 +370,143 @@ func (node *MCSTNode) Expand() bool {
 	}
 	possibleMoves := node.State.GetLegalMoves()
 	node.Children = make([]*MCSTNode, len(possibleMoves))
+	node.ChildMoves = possibleMoves
 
 	for i, move := range possibleMoves {
 		childState := node.State.Copy()
 		childState.RunMove(move)
+		if shared := node.lookupTransposition(childState); shared != nil {
+			node.Children[i] = shared
+			continue
+		}
 		node.Children[i] = &MCSTNode{
 			State: childState,
 			// Player: node.player,
-			Move:     move,
 			Parent:   node,
 			Children: nil,
+			search:   node.search,
 		}
+		if node.search != nil && node.search.table != nil {
+			node.search.table.Put(childState.Hash(), node.Children[i])
+		}
+	}
+	if node.search != nil && node.search.policy != nil {
+		node.search.policy.Expand(node, possibleMoves)
 	}
 	return true
 }
 
+// lookupTransposition returns an existing node for the same position. Only
+// nodes at the same move count are shared, which keeps the graph acyclic.
+func (node *MCSTNode) lookupTransposition(g *game.Game) *MCSTNode {
+	if node.search == nil || node.search.table == nil {
+		return nil
+	}
+	shared, ok := node.search.table.Get(g.Hash())
+	if !ok || shared.State.MoveCount() != g.MoveCount() || shared.State.GetBoard() != g.GetBoard() {
+		return nil
+	}
+	return shared
+}
+
 func (node *MCSTNode) Select() *MCSTNode {
+	path := node.selectPath()
+	return path[len(path)-1]
+}
 
-	if node.State.GetState() != game.Ongoing || node.VisitCount == 0 {
-		return node
-	}
-	expanded := node.Expand()
+// selectPath descends from the node to the node to simulate from and returns
+// every node passed on the way
+func (node *MCSTNode) selectPath() []*MCSTNode {
+	path := []*MCSTNode{node}
+
+	for {
+		if node.State.GetState() != game.Ongoing || node.VisitCount == 0 {
+			return path
+		}
+		expanded := node.Expand()
 
-	var bestChild *MCSTNode
-	maxUCB := math.Inf(-1)
-	for _, child := range node.Children {
-		ucbVal := ucb(child, node.VisitCount)
-		if ucbVal >= maxUCB {
-			maxUCB = ucbVal
-			bestChild = child
+		exploration := DefaultExploration
+		policy := UCB1
+		if node.search != nil {
+			exploration = node.search.exploration
+			policy = node.search.policy
 		}
+
+		var bestChild *MCSTNode
+		maxScore := math.Inf(-1)
+		for i, child := range node.Children {
+			score := policy.Score(node, i, exploration)
+			if score >= maxScore {
+				maxScore = score
+				bestChild = child
+			}
+		}
+
+		if bestChild == nil {
+			panic("No child nodes found")
+		}
+
+		path = append(path, bestChild)
+		if expanded {
+			return path
+		}
+		node = bestChild
 	}
+}
 
-	if bestChild == nil {
-		panic("No child nodes found")
+func (node *MCSTNode) BackPropagate(endState game.GameState) {
+	result := ResultForState(endState)
+	for n := node; n != nil; n = n.Parent {
+		n.update(result)
 	}
+}
 
-	if expanded {
-		return bestChild
-	} else {
+// backPropagatePath updates the nodes along the path the search took, which
+// with a transposition table is not necessarily the Parent chain
+func backPropagatePath(path []*MCSTNode, result PlayoutResult) {
+	for i := len(path) - 1; i >= 0; i-- {
+		path[i].update(result)
+	}
+}
 
-		return bestChild.Select()
+// update counts a visit and credits the node with the result of the player
+// who made the move into it
+func (node *MCSTNode) update(result PlayoutResult) {
+	r := result.For(node.State.NextTurn().NextColor())
+	node.VisitCount++
+	node.WinCount += r
+	node.WinSquares += r * r
+}
+
+// value returns the mean result of the node for the player who made the move
+// into it
+func (node *MCSTNode) value() float64 {
+	if node.VisitCount == 0 {
+		return 0
 	}
+	return node.WinCount / float64(node.VisitCount)
 }
 
-func (node *MCSTNode) BackPropagate(endState game.GameState) {
-	n := node
-
-	for n != nil {
-		n.VisitCount++
-		switch endState {
-		case game.RedWin:
-			if n.State.NextTurn() == board.Blue {
-				n.WinCount++
-			}
-		case game.BlueWin:
-			if n.State.NextTurn() == board.Red {
-				n.WinCount++
+// principalVariation follows the most visited children below the node
+func (node *MCSTNode) principalVariation() []board.Move {
+	var pv []board.Move
+	for node.Children != nil {
+		next := -1
+		for i, child := range node.Children {
+			if child.VisitCount > 0 && (next < 0 || child.VisitCount > node.Children[next].VisitCount) {
+				next = i
 			}
-		case game.Draw:
-			n.WinCount += 0.0
 		}
-		n = n.Parent
+		if next < 0 {
+			break
+		}
+		pv = append(pv, node.ChildMoves[next])
+		node = node.Children[next]
 	}
-
+	return pv
 }
 
-func ucb(node *MCSTNode, parentVisits int) float64 {
+func ucb(node *MCSTNode, parentVisits int, exploration float64) float64 {
 	if node.VisitCount == 0 {
 		return math.Inf(1)
 	}
@@ -222,7 +514,7 @@ func ucb(node *MCSTNode, parentVisits int) float64 {
 	nodeVisits := float64(node.VisitCount)
 	part1 := node.WinCount / nodeVisits
 
-	part2 := math.Sqrt(2.0 * math.Log(float64(parentVisits)) / nodeVisits)
+	part2 := exploration * math.Sqrt(math.Log(float64(parentVisits))/nodeVisits)
 
 	return part1 + part2
 }
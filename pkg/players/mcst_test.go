@@ -0,0 +1,327 @@
+package players
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/eval"
+	"github.com/ytaragin/checkers/pkg/game"
+)
+
+func TestMCSTWorkers(t *testing.T) {
+	g := game.NewGame()
+	player := MCSTPlayer{
+		Color:              board.Red,
+		SelectionAlgorithm: MostVisits,
+		SearchBudget:       SearchBudget{Iterations: 400},
+		Workers:            4,
+		TableSize:          1 << 10,
+		Rand:               NewRand(1),
+	}
+
+	m := player.GetMove(g)
+	if _, err := board.FindMove(g.GetLegalMoves(), board.MoveNotation(m)); err != nil {
+		t.Errorf("expected a legal move, got %s: %v", board.MoveNotation(m), err)
+	}
+}
+
+func TestMCSTTableMoves(t *testing.T) {
+	g := game.NewGame()
+	player := MCSTPlayer{
+		Color:              board.Red,
+		SelectionAlgorithm: MostVisits,
+		SearchBudget:       SearchBudget{Iterations: 3000},
+		TableSize:          1 << 14,
+		Rand:               NewRand(1),
+	}
+	_, roots := player.SearchTree(context.Background(), g, Clock{})
+	if hits, _ := roots[0].search.table.Stats(); hits == 0 {
+		t.Fatalf("expected the search to share nodes through the table")
+	}
+
+	// Every edge must lead from its node by a legal move to its child, even
+	// when the child was created by another parent
+	seen := make(map[*MCSTNode]bool)
+	var check func(node *MCSTNode)
+	check = func(node *MCSTNode) {
+		if seen[node] {
+			return
+		}
+		seen[node] = true
+		for i, child := range node.Children {
+			m := node.ChildMoves[i]
+			if _, err := board.FindMove(node.State.GetLegalMoves(), board.MoveNotation(m)); err != nil {
+				t.Fatalf("move %s is not legal in its node: %v", board.MoveNotation(m), err)
+			}
+			after := node.State.Copy()
+			after.RunMove(m)
+			if after.GetBoard() != child.State.GetBoard() {
+				t.Fatalf("move %s does not lead to its child", board.MoveNotation(m))
+			}
+			check(child)
+		}
+	}
+	check(roots[0])
+}
+
+func TestPersistentMCSTReusesTree(t *testing.T) {
+	g := game.NewGame()
+	player := NewPersistentMCSTPlayer(MCSTPlayer{
+		Color:              board.Red,
+		SelectionAlgorithm: MostVisits,
+		SearchBudget:       SearchBudget{Iterations: 2000},
+		Rand:               NewRand(1),
+	})
+
+	g.RunMove(player.GetMove(g))
+	if player.CarriedVisits() != 0 {
+		t.Errorf("expected nothing to carry over on the first move, got %d", player.CarriedVisits())
+	}
+	g.RunMove(g.GetLegalMoves()[0])
+
+	m := player.GetMove(g)
+	if player.CarriedVisits() == 0 {
+		t.Errorf("expected visits to carry over after the reply")
+	}
+	if _, err := board.FindMove(g.GetLegalMoves(), board.MoveNotation(m)); err != nil {
+		t.Errorf("expected a legal move, got %s: %v", board.MoveNotation(m), err)
+	}
+
+	g.UndoMove()
+	g.UndoMove()
+	player.GetMove(g)
+	if player.CarriedVisits() != 0 {
+		t.Errorf("expected a new tree after going back, got %d visits", player.CarriedVisits())
+	}
+}
+
+func TestPersistentMCSTPonders(t *testing.T) {
+	g := game.NewGame()
+	player := NewPersistentMCSTPlayer(MCSTPlayer{
+		Color:              board.Red,
+		SelectionAlgorithm: MostVisits,
+		SearchBudget:       SearchBudget{Iterations: 100},
+	})
+	player.Ponder = true
+	player.PonderIterations = 3000
+
+	var _ Ponderer = player
+	g.RunMove(player.GetMove(g))
+	player.StartPondering(g.Copy())
+	time.Sleep(50 * time.Millisecond)
+	player.StopPondering()
+	if player.pondered == 0 {
+		t.Fatalf("expected the player to search while pondering")
+	}
+
+	g.RunMove(g.GetLegalMoves()[0])
+	player.GetMove(g)
+	if player.CarriedVisits() == 0 {
+		t.Errorf("expected the pondering to carry over")
+	}
+}
+
+func TestPersistentMCSTWithTable(t *testing.T) {
+	// With a transposition table the kept trees share nodes between
+	// parents, and every move played must still be legal
+	for seed := int64(1); seed <= 2; seed++ {
+		g := game.NewGame()
+		player := NewPersistentMCSTPlayer(MCSTPlayer{
+			Color:              board.Red,
+			SelectionAlgorithm: MostVisits,
+			SearchBudget:       SearchBudget{Iterations: 2000},
+			TableSize:          1 << 14,
+			Rand:               NewRand(seed),
+		})
+		opponent := RandomPlayer{Color: board.Blue, Rand: NewRand(seed + 100)}
+		for ply := 0; ply < 12 && g.GetState() == game.Ongoing; ply++ {
+			var m board.Move
+			if g.NextTurn() == board.Red {
+				m = player.GetMove(g)
+				if _, err := board.FindMove(g.GetLegalMoves(), board.MoveNotation(m)); err != nil {
+					t.Fatalf("seed %d ply %d: illegal move %s: %v", seed, ply, board.MoveNotation(m), err)
+				}
+			} else {
+				m = opponent.GetMove(g)
+			}
+			g.RunMove(m)
+		}
+	}
+}
+
+func TestHeavyPlayout(t *testing.T) {
+	// Red can crown the man on 25 or move the one on 10
+	g, err := game.InitGameFromFEN("B:W5:B10,25")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for seed := int64(1); seed <= 4; seed++ {
+		m := DefaultHeavyPlayout.ChooseMove(g, NewRand(seed))
+		if m.GetStart().Square() != 25 {
+			t.Errorf("expected a crowning move, got %s among %v", board.MoveNotation(m), g.GetLegalMoves())
+		}
+	}
+}
+
+func TestPlayoutCutoff(t *testing.T) {
+	node := &MCSTNode{
+		State:  game.NewGame(),
+		search: &mcstSearch{playoutDepth: 1, weights: eval.Material},
+	}
+	// One move in nobody has lost a piece, so the evaluation is even
+	result := node.Simulate()
+	if result.Red != 0.5 || result.Blue != 0.5 {
+		t.Errorf("expected an even result, got %+v", result)
+	}
+	// A drawn playout scores the same as an even cut off one
+	if draw := ResultForState(game.Draw); draw != result {
+		t.Errorf("expected a draw to score %+v, got %+v", result, draw)
+	}
+}
+
+func TestTreePolicies(t *testing.T) {
+	policies := map[string]TreePolicy{
+		"ucb1":       UCB1,
+		"ucb1-tuned": UCB1Tuned,
+		"puct":       PUCT,
+		"bias":       ProgressiveBias,
+	}
+	for name, policy := range policies {
+		g := game.NewGame()
+		player := MCSTPlayer{
+			Color:              board.Red,
+			SelectionAlgorithm: MostVisits,
+			SearchBudget:       SearchBudget{Iterations: 300},
+			Rand:               NewRand(1),
+			TreePolicy:         policy,
+		}
+		m := player.GetMove(g)
+		if _, err := board.FindMove(g.GetLegalMoves(), board.MoveNotation(m)); err != nil {
+			t.Errorf("%s: expected a legal move, got %s: %v", name, board.MoveNotation(m), err)
+		}
+	}
+
+	// Some of the moves of this position let the opponent capture, so the
+	// heavy playout rules score them lower
+	g := game.NewGame()
+	r := NewRand(3)
+	for i := 0; i < 12; i++ {
+		moves := g.GetLegalMoves()
+		g.RunMove(moves[r.Intn(len(moves))])
+	}
+	root := MCSTPlayer{TreePolicy: PUCT}.newRoot(g, 1)
+	root.Expand()
+	if len(root.ChildPriors) != len(root.ChildMoves) {
+		t.Fatalf("expected a prior for each of the %d moves, got %d", len(root.ChildMoves), len(root.ChildPriors))
+	}
+	scores := DefaultHeavyPlayout.ScoreMoves(g, root.ChildMoves)
+	total := 0.0
+	for _, score := range scores {
+		total += math.Exp(score)
+	}
+	for i, prior := range root.ChildPriors {
+		if want := math.Exp(scores[i]) / total; math.Abs(prior-want) > 1e-9 {
+			t.Errorf("expected %s to have the prior %f of its score %.0f, got %f",
+				board.MoveNotation(root.ChildMoves[i]), want, scores[i], prior)
+		}
+	}
+}
+
+func TestPUCTPriorsWithTable(t *testing.T) {
+	player := MCSTPlayer{
+		Color:              board.Red,
+		SelectionAlgorithm: MostVisits,
+		SearchBudget:       SearchBudget{Iterations: 2000},
+		TableSize:          1 << 14,
+		Rand:               NewRand(1),
+		TreePolicy:         PUCT,
+	}
+	_, roots := player.SearchTree(context.Background(), game.NewGame(), Clock{})
+
+	// A node shared through the table is expanded into by each of its
+	// parents, and each parent must keep the priors of its own moves
+	parents := map[*MCSTNode]*MCSTNode{}
+	shared := 0
+	var walk func(node *MCSTNode)
+	walk = func(node *MCSTNode) {
+		if node.Children == nil {
+			return
+		}
+		fresh := &MCSTNode{State: node.State}
+		PUCT.Expand(fresh, node.ChildMoves)
+		for i, prior := range node.ChildPriors {
+			if math.Abs(prior-fresh.ChildPriors[i]) > 1e-9 {
+				t.Fatalf("expected the prior of %s to be %f, got %f",
+					board.MoveNotation(node.ChildMoves[i]), fresh.ChildPriors[i], prior)
+			}
+		}
+		for _, child := range node.Children {
+			if parent, seen := parents[child]; seen {
+				if parent != node {
+					shared++
+				}
+				continue
+			}
+			parents[child] = node
+			walk(child)
+		}
+	}
+	walk(roots[0])
+	if shared == 0 {
+		t.Errorf("expected the table to share some nodes")
+	}
+}
+
+func TestTreeExport(t *testing.T) {
+	player := MCSTPlayer{
+		Color:              board.Red,
+		SelectionAlgorithm: MostVisits,
+		SearchBudget:       SearchBudget{Iterations: 2000},
+		Rand:               NewRand(1),
+	}
+	_, roots := player.SearchTree(context.Background(), game.NewGame(), Clock{})
+
+	export := TreeExport{MaxDepth: 2, MinVisits: 50}
+	var depth func(n *ExportedNode) int
+	depth = func(n *ExportedNode) int {
+		d := 0
+		for _, child := range n.Children {
+			if child.Visits < 50 {
+				t.Errorf("expected nodes with fewer than 50 visits to be left out, got %d", child.Visits)
+			}
+			d = max(d, depth(child)+1)
+		}
+		return d
+	}
+	tree := export.Tree(roots[0])
+	if d := depth(tree); d != 2 {
+		t.Errorf("expected a tree 2 levels deep, got %d", d)
+	}
+
+	var buf bytes.Buffer
+	if err := export.WriteJSON(&buf, roots...); err != nil {
+		t.Fatal(err)
+	}
+	var trees []*ExportedNode
+	if err := json.Unmarshal(buf.Bytes(), &trees); err != nil {
+		t.Fatalf("expected valid JSON: %v", err)
+	}
+	if len(trees) != 1 || trees[0].Visits != 2000 || len(trees[0].Children) != len(tree.Children) {
+		t.Errorf("expected the JSON to hold the tree, got %+v", trees)
+	}
+
+	buf.Reset()
+	if err := export.WriteDOT(&buf, roots...); err != nil {
+		t.Fatal(err)
+	}
+	dot := buf.String()
+	if !strings.HasPrefix(dot, "digraph mcts {") || !strings.Contains(dot, "n0 -> n1") {
+		t.Errorf("expected a digraph with edges from the root, got\n%s", dot)
+	}
+}
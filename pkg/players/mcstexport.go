@@ -0,0 +1,111 @@
+package players
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/ytaragin/checkers/pkg/board"
+)
+
+// TreeExport writes search trees for inspection, as Graphviz DOT or as JSON.
+// A node reached through a transposition table is written under each of its
+// parents.
+type TreeExport struct {
+	// MaxDepth is the number of levels written below the root. Zero means
+	// the whole tree.
+	MaxDepth int
+	// MinVisits leaves out the nodes visited fewer times
+	MinVisits int
+}
+
+// ExportedNode is a node of an exported tree. WinRate is for the player who
+// made the move into the node, and UCB is the score the tree policy gives the
+// node against its siblings at the end of the search. The root has no UCB,
+// nor does a node that was never visited.
+type ExportedNode struct {
+	Move     string          `json:"move,omitempty"`
+	Visits   int             `json:"visits"`
+	WinRate  float64         `json:"winRate"`
+	UCB      *float64        `json:"ucb,omitempty"`
+	Children []*ExportedNode `json:"children,omitempty"`
+}
+
+// Tree returns the part of the tree under the root chosen by the export
+func (te TreeExport) Tree(root *MCSTNode) *ExportedNode {
+	return te.tree(root, nil, 0, 0)
+}
+
+// tree exports node, which is the i-th child of parent unless it is the root
+func (te TreeExport) tree(node, parent *MCSTNode, i, depth int) *ExportedNode {
+	out := &ExportedNode{Visits: node.VisitCount, WinRate: node.value()}
+	if parent != nil {
+		out.Move = board.MoveNotation(parent.ChildMoves[i])
+		policy, exploration := UCB1, DefaultExploration
+		if parent.search != nil {
+			policy, exploration = parent.search.policy, parent.search.exploration
+		}
+		if ucb := policy.Score(parent, i, exploration); !math.IsInf(ucb, 0) && !math.IsNaN(ucb) {
+			out.UCB = &ucb
+		}
+	}
+	if node.Children == nil || (te.MaxDepth > 0 && depth >= te.MaxDepth) {
+		return out
+	}
+
+	for i, child := range node.Children {
+		if child.VisitCount < te.MinVisits {
+			continue
+		}
+		out.Children = append(out.Children, te.tree(child, node, i, depth+1))
+	}
+	return out
+}
+
+// WriteJSON writes the trees of the roots as a JSON array
+func (te TreeExport) WriteJSON(w io.Writer, roots ...*MCSTNode) error {
+	trees := make([]*ExportedNode, len(roots))
+	for i, root := range roots {
+		trees[i] = te.Tree(root)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(trees)
+}
+
+// WriteDOT writes the trees of the roots as a Graphviz digraph, with a label
+// on each node and the moves on the edges
+func (te TreeExport) WriteDOT(w io.Writer, roots ...*MCSTNode) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph mcts {")
+	fmt.Fprintln(bw, "  node [shape=box, fontname=\"monospace\"];")
+
+	id := 0
+	var write func(n *ExportedNode, label string) int
+	write = func(n *ExportedNode, label string) int {
+		nodeID := id
+		id++
+		label += fmt.Sprintf("\\nvisits %d\\nwin %.3f", n.Visits, n.WinRate)
+		if n.UCB != nil {
+			label += fmt.Sprintf("\\nucb %.3f", *n.UCB)
+		}
+		fmt.Fprintf(bw, "  n%d [label=\"%s\"];\n", nodeID, label)
+		for _, child := range n.Children {
+			childID := write(child, child.Move)
+			fmt.Fprintf(bw, "  n%d -> n%d [label=\"%s\"];\n", nodeID, childID, child.Move)
+		}
+		return nodeID
+	}
+	for i, root := range roots {
+		label := fmt.Sprintf("%s to move", root.State.NextTurn().Name())
+		if len(roots) > 1 {
+			label = fmt.Sprintf("worker %d\\n%s", i, label)
+		}
+		write(te.Tree(root), label)
+	}
+
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
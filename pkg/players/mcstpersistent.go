@@ -0,0 +1,188 @@
+package players
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/game"
+)
+
+// PersistentMCSTPlayer is an MCSTPlayer that keeps its tree between moves.
+// When asked for its next move it looks for the new position below the root
+// of its last search, usually two plies down after its own move and the
+// reply, and carries on from there instead of starting again.
+//
+// With Ponder set the player also implements Ponderer, growing its tree for
+// the position after its own move while the opponent thinks.
+type PersistentMCSTPlayer struct {
+	MCSTPlayer
+	Ponder bool
+	// PonderIterations limits the iterations run per worker while pondering,
+	// which bounds the memory used against a slow opponent. Zero means no
+	// limit.
+	PonderIterations int
+
+	roots   []*MCSTNode
+	carried int
+
+	stopPonder chan struct{}
+	ponderDone chan struct{}
+	pondered   int
+}
+
+// NewPersistentMCSTPlayer creates a player that searches like mc and reuses
+// its tree from one move to the next
+func NewPersistentMCSTPlayer(mc MCSTPlayer) *PersistentMCSTPlayer {
+	return &PersistentMCSTPlayer{MCSTPlayer: mc}
+}
+
+func (p *PersistentMCSTPlayer) GetMove(g *game.Game) board.Move {
+	return p.GetMoveContext(context.Background(), g, Clock{})
+}
+
+// GetMoveContext searches like MCSTPlayer.GetMoveContext, starting from the
+// kept trees
+func (p *PersistentMCSTPlayer) GetMoveContext(ctx context.Context, g *game.Game, clock Clock) board.Move {
+	return p.Search(ctx, g, clock).Move
+}
+
+// Search is GetMoveContext that reports on the search. The iterations and
+// nodes are those of this search, while the visits of the moves include the
+// ones carried over.
+func (p *PersistentMCSTPlayer) Search(ctx context.Context, g *game.Game, clock Clock) SearchInfo {
+	info, _ := p.SearchTree(ctx, g, clock)
+	return info
+}
+
+// SearchTree is Search that also returns the kept trees
+func (p *PersistentMCSTPlayer) SearchTree(ctx context.Context, g *game.Game, clock Clock) (SearchInfo, []*MCSTNode) {
+	p.StopPondering()
+	moves := g.GetLegalMoves()
+	if len(moves) == 1 {
+		return onlyMove(moves[0]), nil
+	}
+
+	mc := p.MCSTPlayer
+	mc.SearchBudget = mc.SearchBudget.forClock(clock)
+	mc = mc.withDefaultBudget()
+	ctx, cancel := clock.moveContext(ctx)
+	defer cancel()
+
+	roots := p.rootsFor(g)
+	if mc.Verbose {
+		fmt.Printf("Visits carried over: %d\n", p.carried)
+	}
+	info := mc.searchRoots(ctx, roots)
+	p.roots = roots
+	return info, roots
+}
+
+// rootsFor returns a root per worker for the position, reusing the kept
+// trees where the position is found in them
+func (p *PersistentMCSTPlayer) rootsFor(g *game.Game) []*MCSTNode {
+	roots := make([]*MCSTNode, max(p.Workers, 1))
+	p.carried = 0
+	for w := range roots {
+		if w < len(p.roots) {
+			roots[w] = p.roots[w].findPosition(g)
+		}
+		if roots[w] == nil {
+			roots[w] = p.newRoot(g, len(roots))
+			continue
+		}
+		// Drop the link to the old root so the rest of the old tree can be
+		// collected
+		roots[w].Parent = nil
+		p.carried += roots[w].VisitCount
+	}
+	return roots
+}
+
+// StartPondering searches the position in the background until
+// StopPondering is called. It does nothing unless Ponder is set.
+func (p *PersistentMCSTPlayer) StartPondering(g *game.Game) {
+	p.StopPondering()
+	if !p.Ponder || g.GetState() != game.Ongoing {
+		return
+	}
+
+	p.roots = p.rootsFor(g)
+	p.stopPonder = make(chan struct{})
+	p.ponderDone = make(chan struct{})
+	counts := make([]int, len(p.roots))
+
+	go func(roots []*MCSTNode, stop <-chan struct{}, done chan<- struct{}) {
+		defer close(done)
+		var wg sync.WaitGroup
+		for w, root := range roots {
+			wg.Add(1)
+			go func(w int, root *MCSTNode) {
+				defer wg.Done()
+				for p.PonderIterations == 0 || counts[w] < p.PonderIterations {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					root.RunLoop()
+					counts[w]++
+				}
+			}(w, root)
+		}
+		wg.Wait()
+
+		p.pondered = 0
+		for _, c := range counts {
+			p.pondered += c
+		}
+	}(p.roots, p.stopPonder, p.ponderDone)
+}
+
+// StopPondering stops the background search and waits for it to finish
+func (p *PersistentMCSTPlayer) StopPondering() {
+	if p.stopPonder == nil {
+		return
+	}
+	close(p.stopPonder)
+	<-p.ponderDone
+	p.stopPonder, p.ponderDone = nil, nil
+	if p.Verbose {
+		fmt.Printf("Pondered: %d iterations\n", p.pondered)
+	}
+}
+
+// CarriedVisits returns the number of visits the last search started with
+// from the trees of earlier moves
+func (p *PersistentMCSTPlayer) CarriedVisits() int {
+	return p.carried
+}
+
+// Reset throws away the kept trees, as when a new game starts
+func (p *PersistentMCSTPlayer) Reset() {
+	p.StopPondering()
+	p.roots = nil
+	p.carried = 0
+}
+
+// findPosition returns the node under this one for the position of the game,
+// or nil if the position was never reached by the search
+func (node *MCSTNode) findPosition(g *game.Game) *MCSTNode {
+	moveCount := node.State.MoveCount()
+	switch {
+	case moveCount > g.MoveCount():
+		return nil
+	case moveCount == g.MoveCount():
+		if node.State.Hash() == g.Hash() && node.State.GetBoard() == g.GetBoard() {
+			return node
+		}
+		return nil
+	}
+	for _, child := range node.Children {
+		if found := child.findPosition(g); found != nil {
+			return found
+		}
+	}
+	return nil
+}
@@ -0,0 +1,342 @@
+package players
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/eval"
+	"github.com/ytaragin/checkers/pkg/game"
+)
+
+// DefaultMinimaxDepth is the depth searched by a player with no MaxDepth or
+// Duration
+const DefaultMinimaxDepth = 8
+
+const (
+	minimaxWinScore   = 10000.0
+	minimaxMaxPly     = 200
+	minimaxCheckEvery = 1024
+)
+
+// MinimaxPlayer searches the game tree with alpha-beta pruning and iterative
+// deepening. The search stops after MaxDepth plies or when Duration runs out,
+// whichever comes first. With only MaxDepth set the search is deterministic,
+// and with neither set it searches to DefaultMinimaxDepth.
+type MinimaxPlayer struct {
+	Color    board.PieceColor
+	MaxDepth int
+	Duration time.Duration
+	Verbose  bool
+	// TableSize enables a transposition table of that many entries
+	TableSize int
+	// Weights score the positions at the end of the search. Nil means
+	// eval.DefaultWeights.
+	Weights *eval.Weights
+}
+
+func (mm MinimaxPlayer) GetMove(g *game.Game) board.Move {
+	return mm.GetMoveContext(context.Background(), g, Clock{})
+}
+
+// GetMoveContext searches until MaxDepth is reached, the duration or the
+// move budget of the clock is spent or ctx is done
+func (mm MinimaxPlayer) GetMoveContext(ctx context.Context, g *game.Game, clock Clock) board.Move {
+	return mm.Search(ctx, g, clock).Move
+}
+
+// Search is GetMoveContext that reports on the search. The statistics of the
+// moves are those of the last completed depth.
+func (mm MinimaxPlayer) Search(ctx context.Context, g *game.Game, clock Clock) SearchInfo {
+
+	moves := g.GetLegalMoves()
+	if len(moves) == 1 {
+		return onlyMove(moves[0])
+	}
+
+	mm.Duration = clock.limitDuration(mm.Duration, mm.MaxDepth)
+	if mm.Duration == 0 && mm.MaxDepth == 0 {
+		mm.MaxDepth = DefaultMinimaxDepth
+	}
+	// A search with only a MaxDepth has no duration, so the move budget is
+	// enforced through ctx
+	ctx, cancel := clock.moveContext(ctx)
+	defer cancel()
+
+	return mm.search(ctx, g)
+}
+
+// GetBestMove runs the iterative deepening search and returns the best move
+// found by the last completed iteration
+func (mm MinimaxPlayer) GetBestMove(g *game.Game) board.Move {
+	return mm.search(context.Background(), g).Move
+}
+
+func (mm MinimaxPlayer) search(ctx context.Context, g *game.Game) SearchInfo {
+	start := time.Now()
+	s := &minimaxSearch{ctx: ctx, weights: eval.DefaultWeights}
+	if mm.Weights != nil {
+		s.weights = *mm.Weights
+	}
+	if mm.TableSize > 0 {
+		s.table = NewTranspositionTable[minimaxEntry](mm.TableSize)
+	}
+	if mm.Duration > 0 {
+		s.timed = true
+		s.deadline = time.Now().Add(mm.Duration)
+	}
+
+	maxDepth := mm.MaxDepth
+	if maxDepth <= 0 || maxDepth > minimaxMaxPly {
+		maxDepth = minimaxMaxPly
+	}
+	s.pv = make([][]board.Move, maxDepth+1)
+
+	moves := orderedMoves(g.GetLegalMoves(), nil)
+	bestMove := moves[0]
+	info := SearchInfo{PV: []board.Move{bestMove}}
+	for depth := 1; depth <= maxDepth; depth++ {
+		move, score := s.searchRoot(g, moves, depth)
+		if s.aborted {
+			break
+		}
+		bestMove = move
+		moves = orderedMoves(moves, bestMove)
+		info.Iterations++
+		info.Depth = depth
+		info.PV = append([]board.Move(nil), s.pv[0]...)
+		info.Moves = append([]MoveStats(nil), s.rootStats...)
+
+		if mm.Verbose {
+			fmt.Printf("Depth: %d Score: %.2f Nodes: %d Move: %s\n", depth, score, s.nodes, bestMove)
+		}
+		if math.Abs(score) >= minimaxWinScore-minimaxMaxPly {
+			break
+		}
+	}
+
+	info.Move = bestMove
+	info.Nodes = s.nodes
+	info.Duration = time.Since(start)
+	return info
+}
+
+type minimaxSearch struct {
+	ctx      context.Context
+	weights  eval.Weights
+	timed    bool
+	deadline time.Time
+	nodes    int
+	aborted  bool
+	table    *TranspositionTable[minimaxEntry]
+	// pv holds the best line found from each ply, pv[0] being the line
+	// from the root
+	pv [][]board.Move
+	// rootStats are the nodes searched under and the scores of the root
+	// moves at the depth being searched
+	rootStats []MoveStats
+}
+
+type boundType int
+
+const (
+	exactBound boundType = iota
+	lowerBound
+	upperBound
+)
+
+// minimaxEntry is what the search remembers about a position
+type minimaxEntry struct {
+	depth    int
+	score    float64
+	bound    boundType
+	bestMove board.Move
+}
+
+func (s *minimaxSearch) searchRoot(g *game.Game, moves []board.Move, depth int) (board.Move, float64) {
+	alpha := math.Inf(-1)
+	beta := math.Inf(1)
+	bestMove := moves[0]
+	s.rootStats = s.rootStats[:0]
+
+	work := g.Copy()
+	for _, m := range moves {
+		nodes := s.nodes
+		if !work.RunMove(m) {
+			continue
+		}
+		score := -s.negamax(work, depth-1, 1, -beta, -alpha)
+		work.UndoMove()
+		if s.aborted {
+			return nil, 0
+		}
+		s.rootStats = append(s.rootStats, MoveStats{
+			Move:   m,
+			Visits: s.nodes - nodes,
+			Value:  scoreValue(score),
+		})
+		if score > alpha {
+			alpha = score
+			bestMove = m
+			s.pv[0] = append(append(s.pv[0][:0], m), s.pv[1]...)
+		}
+	}
+
+	return bestMove, alpha
+}
+
+// negamax returns the score of g from the point of view of the side to move.
+// Moves are made and taken back on g, which is left as it was found.
+func (s *minimaxSearch) negamax(g *game.Game, depth, ply int, alpha, beta float64) float64 {
+	s.nodes++
+	s.pv[ply] = s.pv[ply][:0]
+	if s.nodes%minimaxCheckEvery == 0 && (s.timed && time.Now().After(s.deadline) || s.ctx.Err() != nil) {
+		s.aborted = true
+		return 0
+	}
+
+	switch g.GetState() {
+	case game.Draw:
+		return 0
+	case game.RedWin, game.BlueWin:
+		// The side to move has no moves left. Prefer the quickest win.
+		return -(minimaxWinScore - float64(ply))
+	}
+
+	if depth <= 0 {
+		b := g.GetBoard()
+		return s.weights.Evaluate(&b, g.NextTurn())
+	}
+
+	var hashMove board.Move
+	if s.table != nil {
+		if e, ok := s.table.Get(g.Hash()); ok {
+			hashMove = e.bestMove
+			if e.depth >= depth {
+				score := scoreFromTable(e.score, ply)
+				switch {
+				case e.bound == exactBound:
+					return score
+				case e.bound == lowerBound && score >= beta:
+					return score
+				case e.bound == upperBound && score <= alpha:
+					return score
+				}
+			}
+		}
+	}
+
+	origAlpha := alpha
+	best := math.Inf(-1)
+	var bestMove board.Move
+	for _, m := range orderedMoves(g.GetLegalMoves(), hashMove) {
+		if !g.RunMove(m) {
+			continue
+		}
+		score := -s.negamax(g, depth-1, ply+1, -beta, -alpha)
+		g.UndoMove()
+		if s.aborted {
+			return 0
+		}
+		if score > best {
+			best = score
+			bestMove = m
+		}
+		if score > alpha {
+			alpha = score
+			if ply+1 < len(s.pv) {
+				s.pv[ply] = append(append(s.pv[ply][:0], m), s.pv[ply+1]...)
+			}
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+
+	if s.table != nil {
+		bound := exactBound
+		if best <= origAlpha {
+			bound = upperBound
+		} else if best >= beta {
+			bound = lowerBound
+		}
+		s.table.Put(g.Hash(), minimaxEntry{
+			depth:    depth,
+			score:    scoreToTable(best, ply),
+			bound:    bound,
+			bestMove: bestMove,
+		})
+	}
+
+	return best
+}
+
+// scoreValue turns a score into an expected score from 0 to 1
+func scoreValue(score float64) float64 {
+	switch {
+	case score >= minimaxWinScore-minimaxMaxPly:
+		return 1
+	case score <= -(minimaxWinScore - minimaxMaxPly):
+		return 0
+	}
+	return eval.WinProbability(score)
+}
+
+// scoreToTable makes win scores relative to the stored position instead of
+// the root so they stay valid when the position is reached at another ply
+func scoreToTable(score float64, ply int) float64 {
+	if score >= minimaxWinScore-minimaxMaxPly {
+		return score + float64(ply)
+	}
+	if score <= -(minimaxWinScore - minimaxMaxPly) {
+		return score - float64(ply)
+	}
+	return score
+}
+
+func scoreFromTable(score float64, ply int) float64 {
+	if score >= minimaxWinScore-minimaxMaxPly {
+		return score - float64(ply)
+	}
+	if score <= -(minimaxWinScore - minimaxMaxPly) {
+		return score + float64(ply)
+	}
+	return score
+}
+
+// orderedMoves returns the moves with first moved to the front. Moves are
+// made afresh whenever they are generated, so first is matched by its squares
+// rather than by identity. If first is not one of the moves the order is left
+// unchanged.
+func orderedMoves(moves []board.Move, first board.Move) []board.Move {
+	at := -1
+	for i, m := range moves {
+		if first != nil && sameMove(m, first) {
+			at = i
+			break
+		}
+	}
+	ordered := make([]board.Move, 0, len(moves))
+	if at >= 0 {
+		ordered = append(ordered, moves[at])
+	}
+	for i, m := range moves {
+		if i != at {
+			ordered = append(ordered, m)
+		}
+	}
+	return ordered
+}
+
+// sameMove reports whether a and b go through the same squares
+func sameMove(a, b board.Move) bool {
+	if a == b {
+		return true
+	}
+	if a.GetStart().Square() != b.GetStart().Square() || a.GetEnd().Square() != b.GetEnd().Square() {
+		return false
+	}
+	return board.MoveNotation(a) == board.MoveNotation(b)
+}
@@ -0,0 +1,67 @@
+package players
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/game"
+)
+
+func TestMinimaxFindsForcedWin(t *testing.T) {
+	// Only the quiet king move 24-20 forces a win, six plies later. A search
+	// of one or two plies plays 10-15 instead.
+	g, err := game.InitGameFromFEN("B:W14,16,17:BK24,10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	player := MinimaxPlayer{Color: board.Red, MaxDepth: 7}
+	if notation := board.MoveNotation(player.GetMove(g)); notation != "24-20" {
+		t.Errorf("expected the winning 24-20, got %s", notation)
+	}
+}
+
+func TestMinimaxDeterministic(t *testing.T) {
+	g := game.NewGame()
+	g.RunMove(g.GetLegalMoves()[2])
+	player := MinimaxPlayer{Color: board.Blue, MaxDepth: 5}
+	first := board.MoveNotation(player.GetMove(g))
+	if second := board.MoveNotation(player.GetMove(g)); second != first {
+		t.Errorf("expected the same move from the same depth, got %s and %s", first, second)
+	}
+}
+
+func TestOrderedMovesMatchesSquares(t *testing.T) {
+	// Red has four double captures, two from each man
+	fen := "B:W14,15,22,23:B10,11"
+	stored, err := game.InitGameFromFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := game.InitGameFromFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A move kept in the table from another search of the position is a
+	// different value from the moves generated now
+	hashMove := stored.GetLegalMoves()[3]
+	ordered := orderedMoves(g.GetLegalMoves(), hashMove)
+	if len(ordered) != 4 || board.MoveNotation(ordered[0]) != board.MoveNotation(hashMove) {
+		t.Errorf("expected %s first, got %v", board.MoveNotation(hashMove), ordered)
+	}
+}
+
+func TestMinimaxMoveBudget(t *testing.T) {
+	// A depth that takes far longer than the move budget of the clock
+	player := MinimaxPlayer{Color: board.Red, MaxDepth: 40}
+	clock := Clock{Remaining: 3 * time.Second}
+	start := time.Now()
+	m := player.GetMoveContext(context.Background(), game.NewGame(), clock)
+	if elapsed := time.Since(start); elapsed > clock.MoveBudget()+time.Second {
+		t.Errorf("expected the search to stop after the move budget of %s, took %s", clock.MoveBudget(), elapsed)
+	}
+	if m == nil {
+		t.Errorf("expected a move")
+	}
+}
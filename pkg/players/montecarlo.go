@@ -1,9 +1,12 @@
 package players
 
 import (
-	"fmt"
+	"context"
+	"math"
 	"math/rand"
+	"os"
 	"sync"
+	"time"
 
 	"github.com/ytaragin/checkers/pkg/board"
 	"github.com/ytaragin/checkers/pkg/game"
@@ -12,42 +15,142 @@ import (
 type MCPlayer struct {
 	Color   board.PieceColor
 	Verbose bool
+	// SearchBudget limits the search of each move. An iteration plays out
+	// every candidate move once, and without a limit the player runs 5000
+	// of them. With an ExplorationWeight set the playouts of an iteration go
+	// to the moves picked by UCB1 instead, so the promising moves get more
+	// of them.
+	SearchBudget
+	// Rand is the source of the random playouts. Nil uses the global source.
+	// A player with its own source must not be used by several goroutines.
+	Rand *rand.Rand
 }
 
 func (mc MCPlayer) GetMove(g *game.Game) board.Move {
+	return mc.GetMoveContext(context.Background(), g, Clock{})
+}
+
+// GetMoveContext searches until the search budget is used up, the move
+// budget of the clock is spent or ctx is done
+func (mc MCPlayer) GetMoveContext(ctx context.Context, g *game.Game, clock Clock) board.Move {
+	return mc.Search(ctx, g, clock).Move
+}
+
+// Search is GetMoveContext that reports on the search. An iteration is a
+// playout of every move.
+func (mc MCPlayer) Search(ctx context.Context, g *game.Game, clock Clock) SearchInfo {
 
 	moves := g.GetLegalMoves()
 	if len(moves) == 1 {
-		return moves[0]
+		return onlyMove(moves[0])
 	}
-	iterations := 5000
-	bestMove := mc.GetBestMove(g, iterations)
 
-	return bestMove
+	ctx, cancel := clock.moveContext(ctx)
+	defer cancel()
+	budget := mc.forClock(clock).withDefaultIterations(5000)
+	return mc.search(ctx, g, budget)
 }
 
+// GetBestMove returns the best move after the given number of iterations
 func (mc MCPlayer) GetBestMove(g *game.Game, iterations int) board.Move {
+	budget := mc.SearchBudget
+	budget.Iterations = iterations
+	return mc.search(context.Background(), g, budget).Move
+}
 
+// search plays out the moves until the budget is used up and returns the one
+// with the best average score, or with UCB1 the most played one
+func (mc MCPlayer) search(ctx context.Context, g *game.Game, budget SearchBudget) SearchInfo {
+	start := time.Now()
 	possibleMoves := g.GetLegalMoves()
-	scoreMap := make(map[board.Move]float64)
+	scores := make([]float64, len(possibleMoves))
+	visits := make([]int, len(possibleMoves))
+	total := 0
+
+	run := budget.start(ctx)
+	for !run.done() {
+		nodes := 0
+		for k := range possibleMoves {
+			i := k
+			if budget.ExplorationWeight > 0 {
+				i = flatUCB(scores, visits, total, budget.ExplorationWeight)
+			}
+			score, plies := mc.playout(g, possibleMoves[i])
+			scores[i] += score
+			visits[i]++
+			total++
+			nodes += plies
+		}
+		run.add(nodes, 1)
+	}
 
-	for _, move := range possibleMoves {
-		// scoreMap[move] = mc.RunMonteCarloMT(g, move, iterations, 3)
-		scoreMap[move] = mc.RunMonteCarlo(g, move, iterations)
+	info := SearchInfo{
+		Iterations: run.iterations,
+		Nodes:      run.nodes,
+		Depth:      run.depth,
+	}
+	best := 0
+	bestStat := math.Inf(-1)
+	// The moves are compared in order so that a seeded player always breaks
+	// ties the same way
+	for i, move := range possibleMoves {
+		stats := MoveStats{Move: move, Visits: visits[i]}
+		info.Moves = append(info.Moves, stats)
+		if visits[i] == 0 {
+			continue
+		}
+		mean := scores[i] / float64(visits[i])
+		// The scores go from -1 for a loss to 1 for a win
+		info.Moves[i].Value = (mean + 1) / 2
+		stat := mean
+		if budget.ExplorationWeight > 0 {
+			stat = float64(visits[i])
+		}
+		if stat > bestStat {
+			bestStat = stat
+			best = i
+		}
 	}
 
-	var bestMove board.Move
-	highestScore := -5.0
-	for move, score := range scoreMap {
-		if mc.Verbose {
-			fmt.Printf("%s %.2f\n", move, score)
+	info.Move = possibleMoves[best]
+	info.PV = []board.Move{info.Move}
+	info.Duration = time.Since(start)
+	if mc.Verbose {
+		info.Print(os.Stdout)
+	}
+	return info
+}
+
+// flatUCB returns the index of the move with the highest UCB1 value, with the
+// scores taken from -1..1 to 0..1
+func flatUCB(scores []float64, visits []int, total int, exploration float64) int {
+	best := 0
+	bestValue := math.Inf(-1)
+	for i := range scores {
+		if visits[i] == 0 {
+			return i
 		}
-		if score > highestScore {
-			highestScore = score
-			bestMove = move
+		n := float64(visits[i])
+		value := (scores[i]/n+1)/2 + exploration*math.Sqrt(math.Log(float64(total))/n)
+		if value > bestValue {
+			bestValue = value
+			best = i
 		}
 	}
-	return bestMove
+	return best
+}
+
+// playout plays the move and then random moves to the end of the game. It
+// returns the score of the game and the number of positions passed through.
+func (mc MCPlayer) playout(g *game.Game, move board.Move) (float64, int) {
+	gtemp := *g
+	gtemp.RunMove(move)
+	plies := 1
+	for gtemp.GetState() == game.Ongoing {
+		gtemp.RunMove(mc.GetRandomMove(&gtemp))
+		plies++
+	}
+	return mc.EvaluateScore(&gtemp), plies
 }
 
 // EvaluateScore evaluates the score or outcome of the given game state
@@ -72,7 +175,7 @@ func (mc MCPlayer) EvaluateScore(g *game.Game) float64 {
 
 func (mc MCPlayer) GetRandomMove(g *game.Game) board.Move {
 	moves := g.GetLegalMoves()
-	randomIndex := rand.Intn(len(moves))
+	randomIndex := randIntn(mc.Rand, len(moves))
 
 	randomMove := moves[randomIndex]
 
@@ -83,12 +186,8 @@ func (mc MCPlayer) GetRandomMove(g *game.Game) board.Move {
 func (mc MCPlayer) RunMonteCarlo(g *game.Game, move board.Move, iterations int) float64 {
 	totalScore := 0.0
 	for i := 0; i < iterations; i++ {
-		gtemp := *g
-		gtemp.RunMove(move)
-		for gtemp.GetState() == game.Ongoing {
-			gtemp.RunMove(mc.GetRandomMove(&gtemp))
-		}
-		totalScore += mc.EvaluateScore(&gtemp)
+		score, _ := mc.playout(g, move)
+		totalScore += score
 	}
 	return totalScore / float64(iterations)
 }
@@ -107,6 +206,12 @@ func (mc MCPlayer) RunMonteCarloMT(g *game.Game, move board.Move, iterations int
 	wg.Add(workerCount)
 
 	for i := 0; i < workerCount; i++ {
+		// A rand.Rand is not safe for concurrent use, so each worker gets
+		// its own source seeded from the player's
+		worker := mc
+		if mc.Rand != nil {
+			worker.Rand = NewRand(mc.Rand.Int63())
+		}
 		go func(workerId int) {
 			defer wg.Done()
 			iterationsForWorker := numIterationsPerWorker
@@ -120,10 +225,10 @@ func (mc MCPlayer) RunMonteCarloMT(g *game.Game, move board.Move, iterations int
 				gtemp.RunMove(move)
 
 				for gtemp.GetState() == game.Ongoing {
-					gtemp.RunMove(mc.GetRandomMove(&gtemp))
+					gtemp.RunMove(worker.GetRandomMove(&gtemp))
 				}
 
-				workerScore += mc.EvaluateScore(&gtemp)
+				workerScore += worker.EvaluateScore(&gtemp)
 			}
 
 			scoreChan <- workerScore
@@ -1,31 +1,111 @@
 package players
 
 import (
+	"context"
+	"fmt"
 	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"time"
 
 	"github.com/ytaragin/checkers/pkg/board"
 	"github.com/ytaragin/checkers/pkg/game"
 )
 
+// MCPlayerRave is a tree search that shares what it learns about a move
+// between all the positions the move can be played in. Every move played by
+// a side during a simulation, in the tree or in the playout, counts towards
+// the All-Moves-As-First (AMAF) statistics of the sibling nodes for that
+// move. Selection blends the AMAF value into the node's own value, trusting
+// it less as the node gets visits.
 type MCPlayerRave struct {
 	Color   board.PieceColor
 	Verbose bool
+	// TableSize enables a transposition table of that many entries so that
+	// positions reached by different move orders share a single node
+	TableSize int
+	// SearchBudget limits the search of each move. Without a limit the
+	// player runs 5000 simulations. Its ExplorationWeight defaults to 1.
+	SearchBudget
+	// Schedule decides how much the AMAF value counts. Nil means an
+	// EquivalenceSchedule with K of 1000.
+	Schedule RaveSchedule
+	// Playout chooses the moves of the simulated games. Nil means
+	// RandomPlayout.
+	Playout PlayoutPolicy
+	// Rand is the source of the random playouts. Nil uses the global source.
+	Rand  *rand.Rand
+	table *TranspositionTable[*Node]
 }
 
+// RaveSchedule gives the weight beta of the AMAF value of a node, between 0
+// and 1, from its visits and its AMAF visits
+type RaveSchedule interface {
+	Beta(visits, raveVisits int) float64
+}
+
+// EquivalenceSchedule is the hand picked schedule of Gelly and Silver. K is
+// the number of visits at which the node's own value and the AMAF value get
+// equal weight.
+type EquivalenceSchedule struct {
+	K float64
+}
+
+func (s EquivalenceSchedule) Beta(visits, raveVisits int) float64 {
+	return math.Sqrt(s.K / (3*float64(visits) + s.K))
+}
+
+// MinimumMSESchedule is the schedule of Gelly and Silver that minimises the
+// mean squared error of the blended value, given the Bias of the AMAF value
+type MinimumMSESchedule struct {
+	Bias float64
+}
+
+func (s MinimumMSESchedule) Beta(visits, raveVisits int) float64 {
+	n, rn := float64(visits), float64(raveVisits)
+	if n+rn == 0 {
+		return 1
+	}
+	return rn / (n + rn + 4*s.Bias*s.Bias*n*rn)
+}
+
+// DefaultRaveSchedule is the schedule used when none is set
+var DefaultRaveSchedule RaveSchedule = EquivalenceSchedule{K: 1000}
+
 func (mcr MCPlayerRave) GetMove(g *game.Game) board.Move {
+	return mcr.GetMoveContext(context.Background(), g, Clock{})
+}
+
+// GetMoveContext searches until the search budget is used up, the move
+// budget of the clock is spent or ctx is done
+func (mcr MCPlayerRave) GetMoveContext(ctx context.Context, g *game.Game, clock Clock) board.Move {
+	return mcr.Search(ctx, g, clock).Move
+}
+
+// Search is GetMoveContext that reports on the search
+func (mcr MCPlayerRave) Search(ctx context.Context, g *game.Game, clock Clock) SearchInfo {
 
 	moves := g.GetLegalMoves()
 	if len(moves) == 1 {
-		return moves[0]
+		return onlyMove(moves[0])
 	}
 
-	rootNode := mcr.CreateRootNode(&mcr, g)
-	iterations := 5000
-	explorationWeight := 1.0
-	bestMove := rootNode.findBestMove(iterations, explorationWeight)
-	// fmt.Printf("Best move: %v\n", bestMove)
+	if mcr.TableSize > 0 {
+		mcr.table = NewTranspositionTable[*Node](mcr.TableSize)
+	}
+	if mcr.Schedule == nil {
+		mcr.Schedule = DefaultRaveSchedule
+	}
+	if mcr.Playout == nil {
+		mcr.Playout = RandomPlayout{}
+	}
+	ctx, cancel := clock.moveContext(ctx)
+	defer cancel()
+	budget := mcr.forClock(clock).withDefaultIterations(5000)
 
-	return bestMove
+	rootNode := mcr.CreateRootNode(&mcr, g)
+	return rootNode.findBestMove(budget.start(ctx), budget.exploration(1.0))
 }
 
 func (mcr MCPlayerRave) EvaluateScore(g *game.Game) float64 {
@@ -47,20 +127,22 @@ func (mcr MCPlayerRave) EvaluateScore(g *game.Game) float64 {
 	return score
 }
 
-// Node represents a node in the MCTS search tree
+// Node represents a node in the MCTS search tree. Its values are from the
+// point of view of the player who made the move into it.
 type Node struct {
-	player     *MCPlayerRave
-	move       board.Move
-	state      game.Game
-	parent     *Node
-	children   []*Node
+	player   *MCPlayerRave
+	state    game.Game
+	parent   *Node
+	children []*Node
+	// childMoves are the moves leading to the children. They belong to the
+	// edges, as with a transposition table a child can be reached from
+	// several parents by different moves.
+	childMoves []board.Move
 	visits     int
 	value      float64
 	rave       float64
 	raveVisits int
 }
-type MCMove struct {
-}
 
 // CreateRootNode creates the root node for the MCTS search tree
 func (mcr MCPlayerRave) CreateRootNode(player *MCPlayerRave, g *game.Game) *Node {
@@ -70,108 +152,251 @@ func (mcr MCPlayerRave) CreateRootNode(player *MCPlayerRave, g *game.Game) *Node
 	}
 }
 
-// UCT computes the Upper Confidence Bounds (UCB) value for a node
+// UCT computes the Upper Confidence Bounds (UCB) value for a node, using the
+// blend of its own and its AMAF value
 func (node *Node) UCT(totalVisits int, explorationWeight float64) float64 {
-	if node.visits == 0 {
+	if node.visits == 0 && node.raveVisits == 0 {
 		return math.Inf(1)
 	}
-	return node.value/float64(node.visits) + explorationWeight*math.Sqrt(2*math.Log(float64(totalVisits))/float64(node.visits))
+	visits := float64(max(node.visits, 1))
+	return node.Value() + explorationWeight*math.Sqrt(2*math.Log(float64(max(totalVisits, 1)))/visits)
 }
 
-// RAVE computes the Rapid Action Value Estimation (RAVE) value for a node
-func (node *Node) RAVE(explorationWeight float64) float64 {
+// RAVE returns the AMAF value of the node
+func (node *Node) RAVE() float64 {
+	if node.raveVisits == 0 {
+		return 0
+	}
+	return node.rave / float64(node.raveVisits)
+}
+
+// Value blends the node's own value with its AMAF value by the player's
+// schedule
+func (node *Node) Value() float64 {
+	if node.visits == 0 && node.raveVisits == 0 {
+		return 0
+	}
 	if node.raveVisits == 0 {
 		return node.value / float64(node.visits)
 	}
-	beta := explorationWeight / float64(1+node.raveVisits)
-	return (1-beta)*node.value/float64(node.visits) + beta*node.rave/float64(node.raveVisits)
+	if node.visits == 0 {
+		return node.RAVE()
+	}
+	schedule := DefaultRaveSchedule
+	if node.player != nil && node.player.Schedule != nil {
+		schedule = node.player.Schedule
+	}
+	beta := schedule.Beta(node.visits, node.raveVisits)
+	return (1-beta)*node.value/float64(node.visits) + beta*node.RAVE()
 }
 
 // Select selects the best child node based on UCT and RAVE values
 func (node *Node) Select(totalVisits int, explorationWeight float64) *Node {
-	var bestChild *Node
+	return node.children[node.selectChild(totalVisits, explorationWeight)]
+}
+
+// selectChild returns the index of the child Select chooses
+func (node *Node) selectChild(totalVisits int, explorationWeight float64) int {
+	best := -1
 	bestValue := -math.Inf(1)
 
-	for _, child := range node.children {
+	for i, child := range node.children {
 		value := child.UCT(totalVisits, explorationWeight)
 		if value > bestValue {
 			bestValue = value
-			bestChild = child
+			best = i
 		}
 	}
 
-	if bestChild == nil {
+	if best < 0 {
 		panic("No child nodes found")
 	}
 
-	return bestChild
+	return best
 }
 
 // Expand expands the current node by creating child nodes for each possible move
 func (node *Node) expand() {
 	possibleMoves := node.state.GetLegalMoves()
 	node.children = make([]*Node, len(possibleMoves))
+	node.childMoves = possibleMoves
 
+	table := node.player.table
 	for i, move := range possibleMoves {
 		childState := node.state
 		childState.RunMove(move)
+		if table != nil {
+			shared, ok := table.Get(childState.Hash())
+			if ok && shared.state.MoveCount() == childState.MoveCount() && shared.state.GetBoard() == childState.GetBoard() {
+				node.children[i] = shared
+				continue
+			}
+		}
 		node.children[i] = &Node{
 			state:  childState,
 			player: node.player,
-			move:   move,
 			parent: node,
 		}
+		if table != nil {
+			table.Put(childState.Hash(), node.children[i])
+		}
 	}
 }
 
-// BackPropagateValue backpropagates the value of a terminal state up the tree
-func (node *Node) backPropagateValue(value float64) {
-	for n := node; n != nil; n = n.parent {
-		n.visits++
-		n.value += value
-		n.rave = (n.rave*float64(n.raveVisits) + value) / float64(n.raveVisits+1)
-		n.raveVisits++
-	}
+func (node *Node) update(result PlayoutResult) {
+	node.visits++
+	node.value += result.For(node.state.NextTurn().NextColor())
 }
 
-// Simulate performs a Monte Carlo simulation from the current node
-func (node *Node) simulate(explorationWeight float64) float64 {
-	currentState := node.state
-	totalVisits := node.visits
+// amafKey identifies a move by its start and end squares, so the same move
+// can be recognised in different positions
+func amafKey(m board.Move) int {
+	return m.GetStart().Square()<<8 | m.GetEnd().Square()
+}
+
+// simulate runs one iteration: it descends the tree to a node that has not
+// been visited, plays the game out from there and updates the nodes it passed
+// and the AMAF statistics of their children. It returns the result, the
+// number of positions the iteration passed through and the depth it reached
+// in the tree.
+func (node *Node) simulate(explorationWeight float64) (PlayoutResult, int, int) {
+	player := node.player
+	// With a transposition table nodes can have several parents, so the
+	// path taken is recorded rather than following parent pointers
+	path := []*Node{node}
+	// played[i] is the AMAF key of the move made from path[i], followed by
+	// the keys of the playout moves
+	var played []int
+	var players []board.PieceColor
 
-	for currentState.GetState() == game.Ongoing {
-		if len(node.children) == 0 {
+	for node.state.GetState() == game.Ongoing && node.visits > 0 {
+		if node.children == nil {
 			node.expand()
 		}
-		node = node.Select(totalVisits, explorationWeight)
-		currentState = node.state
-		totalVisits += node.visits
+		i := node.selectChild(node.visits, explorationWeight)
+		played = append(played, amafKey(node.childMoves[i]))
+		players = append(players, node.state.NextTurn())
+		node = node.children[i]
+		path = append(path, node)
+	}
+
+	playout := node.state
+	policy := PlayoutPolicy(RandomPlayout{})
+	var r *rand.Rand
+	if player != nil {
+		policy, r = player.Playout, player.Rand
 	}
+	for playout.GetState() == game.Ongoing {
+		m := policy.ChooseMove(&playout, r)
+		played = append(played, amafKey(m))
+		players = append(players, playout.NextTurn())
+		playout.RunMove(m)
+	}
+	result := ResultForState(playout.GetState())
+
+	// Walk back up the path, collecting the moves each side played from the
+	// node down
+	seen := map[board.PieceColor]map[int]bool{board.Red: {}, board.Blue: {}}
+	for i := len(path) - 1; i < len(played); i++ {
+		seen[players[i]][played[i]] = true
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		if i < len(path)-1 {
+			seen[players[i]][played[i]] = true
+		}
+		n := path[i]
+		n.update(result)
 
-	value := node.player.EvaluateScore(&node.state)
-	node.backPropagateValue(value)
-	return value
+		color := n.state.NextTurn()
+		for j, child := range n.children {
+			if seen[color][amafKey(n.childMoves[j])] {
+				child.raveVisits++
+				child.rave += result.For(color)
+			}
+		}
+	}
+	return result, len(played) + 1, len(path) - 1
 }
 
-// FindBestMove performs the MCTS search and returns the best move
-func (rootNode *Node) findBestMove(iterations int, explorationWeight float64) board.Move {
-	for i := 0; i < iterations; i++ {
-		rootNode.simulate(explorationWeight)
+// FindBestMove performs the MCTS search until the run's budget is used up and
+// returns the best move with the statistics of the search
+func (rootNode *Node) findBestMove(run *budgetRun, explorationWeight float64) SearchInfo {
+	start := time.Now()
+	for !run.done() {
+		_, nodes, depth := rootNode.simulate(explorationWeight)
+		run.add(nodes, depth)
+	}
+	if rootNode.children == nil {
+		rootNode.expand()
 	}
 
 	var bestChild *Node
 	bestVisits := -5
 
-	for _, child := range rootNode.children {
+	info := SearchInfo{
+		Iterations: run.iterations,
+		Nodes:      run.nodes,
+		Depth:      run.depth,
+	}
+	moves := rootNode.childMoves
+	for i, child := range rootNode.children {
+		info.Moves = append(info.Moves, MoveStats{Move: moves[i], Visits: child.visits, Value: child.Value()})
 		if child.visits > bestVisits {
 			bestVisits = child.visits
 			bestChild = child
+			info.Move = moves[i]
 		}
 	}
 
 	if bestChild == nil {
 		panic("No best move found")
 	}
+	info.PV = append([]board.Move{info.Move}, bestChild.principalVariation()...)
+	info.Duration = time.Since(start)
 
-	return bestChild.move
+	if rootNode.player != nil && rootNode.player.Verbose {
+		info.Print(os.Stdout)
+		rootNode.printChildren()
+	}
+	return info
+}
+
+// principalVariation follows the most visited children below the node
+func (node *Node) principalVariation() []board.Move {
+	var pv []board.Move
+	for node.children != nil {
+		next := -1
+		for i, child := range node.children {
+			if child.visits > 0 && (next < 0 || child.visits > node.children[next].visits) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		pv = append(pv, node.childMoves[next])
+		node = node.children[next]
+	}
+	return pv
+}
+
+// printChildren shows the AMAF statistics of the root moves, most visited
+// first
+func (rootNode *Node) printChildren() {
+	order := make([]int, len(rootNode.children))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return rootNode.children[order[a]].visits > rootNode.children[order[b]].visits
+	})
+	for _, i := range order {
+		child := rootNode.children[i]
+		value := 0.0
+		if child.visits > 0 {
+			value = child.value / float64(child.visits)
+		}
+		fmt.Printf("%-10s Visits: %6d Value: %.3f AMAF: %.3f (%d) Blend: %.3f\n",
+			board.MoveNotation(rootNode.childMoves[i]), child.visits, value, child.RAVE(), child.raveVisits, child.Value())
+	}
 }
@@ -0,0 +1,85 @@
+package players
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/game"
+)
+
+func TestRaveAMAFStatistics(t *testing.T) {
+	player := MCPlayerRave{Color: board.Red, Playout: RandomPlayout{}, Rand: NewRand(1)}
+	root := player.CreateRootNode(&player, game.NewGame())
+	for i := 0; i < 500; i++ {
+		root.simulate(1)
+	}
+
+	visits, raveVisits := 0, 0
+	for i, child := range root.children {
+		// Every visit to a child also plays its move from the root
+		if child.raveVisits < child.visits {
+			t.Errorf("%s: expected at least %d AMAF visits, got %d",
+				board.MoveNotation(root.childMoves[i]), child.visits, child.raveVisits)
+		}
+		visits += child.visits
+		raveVisits += child.raveVisits
+	}
+	if visits != 499 {
+		t.Errorf("expected 499 visits below the root, got %d", visits)
+	}
+	if raveVisits <= visits {
+		t.Errorf("expected moves played later in the simulations to count, got %d AMAF visits for %d visits",
+			raveVisits, visits)
+	}
+}
+
+func TestRaveTableMoves(t *testing.T) {
+	player := MCPlayerRave{Color: board.Red, Playout: RandomPlayout{}, Rand: NewRand(1)}
+	player.table = NewTranspositionTable[*Node](1 << 14)
+	root := player.CreateRootNode(&player, game.NewGame())
+	for i := 0; i < 3000; i++ {
+		root.simulate(1)
+	}
+	if hits, _ := player.table.Stats(); hits == 0 {
+		t.Fatalf("expected the search to share nodes through the table")
+	}
+
+	// The AMAF statistics are keyed by the moves on the edges, which must
+	// lead from each node to its children even when a child is shared
+	seen := make(map[*Node]bool)
+	var check func(node *Node)
+	check = func(node *Node) {
+		if seen[node] {
+			return
+		}
+		seen[node] = true
+		for i, child := range node.children {
+			after := node.state
+			after.RunMove(node.childMoves[i])
+			if after.GetBoard() != child.state.GetBoard() {
+				t.Fatalf("move %s does not lead to its child", board.MoveNotation(node.childMoves[i]))
+			}
+			check(child)
+		}
+	}
+	check(root)
+}
+
+func TestRaveSchedules(t *testing.T) {
+	eq := EquivalenceSchedule{K: 300}
+	if beta := eq.Beta(0, 10); beta != 1 {
+		t.Errorf("expected an unvisited node to use only AMAF, got %f", beta)
+	}
+	if beta := eq.Beta(100, 1000); math.Abs(beta-math.Sqrt(0.5)) > 1e-9 {
+		t.Errorf("expected beta of sqrt(1/2) at K/3 visits, got %f", beta)
+	}
+
+	mse := MinimumMSESchedule{}
+	if beta := mse.Beta(100, 300); beta != 0.75 {
+		t.Errorf("expected an unbiased AMAF value to be weighted by its share of visits, got %f", beta)
+	}
+	if biased := (MinimumMSESchedule{Bias: 0.1}).Beta(100, 300); biased >= 0.75 {
+		t.Errorf("expected bias to lower beta, got %f", biased)
+	}
+}
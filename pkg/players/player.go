@@ -5,6 +5,22 @@ import (
 	"github.com/ytaragin/checkers/pkg/game"
 )
 
+// Player chooses the next move of a game. Returning nil resigns the game.
 type Player interface {
 	GetMove(g *game.Game) board.Move
 }
+
+// Ponderer is a player that can think on its opponent's time. The GameRunner
+// calls StartPondering with the position after the player's own move and
+// StopPondering before asking it for its next move or when the game ends.
+type Ponderer interface {
+	Player
+	StartPondering(g *game.Game)
+	StopPondering()
+}
+
+// PlayerFactory creates a player for the given color. Some players, such as
+// MCPlayer, score positions for the color they were created with, so a new
+// player is needed whenever the colors change. The seed lets a game be
+// replayed with the same random choices.
+type PlayerFactory func(color board.PieceColor, seed int64) Player
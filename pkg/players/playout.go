@@ -0,0 +1,118 @@
+package players
+
+import (
+	"math/rand"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/eval"
+	"github.com/ytaragin/checkers/pkg/game"
+)
+
+// PlayoutPolicy chooses the moves of the simulated games of a tree search
+type PlayoutPolicy interface {
+	ChooseMove(g *game.Game, r *rand.Rand) board.Move
+}
+
+// RandomPlayout plays uniformly random moves
+type RandomPlayout struct{}
+
+func (RandomPlayout) ChooseMove(g *game.Game, r *rand.Rand) board.Move {
+	moves := g.GetLegalMoves()
+	return moves[randIntn(r, len(moves))]
+}
+
+// HeavyPlayout scores every move with simple rules and plays one of the best,
+// which makes the simulated games closer to real ones at the cost of speed
+type HeavyPlayout struct {
+	// CaptureWeight is added per piece a move captures, preferring the
+	// bigger captures when there is a choice
+	CaptureWeight float64
+	// PromotionWeight is added for a move that crowns a man
+	PromotionWeight float64
+	// SafetyWeight is taken off a move that lets the opponent capture. It
+	// needs the move to be played out, so it is the slowest rule.
+	SafetyWeight float64
+}
+
+// DefaultHeavyPlayout uses all the rules
+var DefaultHeavyPlayout = HeavyPlayout{CaptureWeight: 1, PromotionWeight: 1, SafetyWeight: 1}
+
+func (hp HeavyPlayout) ChooseMove(g *game.Game, r *rand.Rand) board.Move {
+	moves := g.GetLegalMoves()
+	if len(moves) == 1 {
+		return moves[0]
+	}
+
+	var best []board.Move
+	bestScore := 0.0
+	for i, score := range hp.ScoreMoves(g, moves) {
+		switch {
+		case best == nil || score > bestScore:
+			best = append(best[:0], moves[i])
+			bestScore = score
+		case score == bestScore:
+			best = append(best, moves[i])
+		}
+	}
+	return best[randIntn(r, len(best))]
+}
+
+// ScoreMoves scores the moves by the rules, which makes HeavyPlayout a
+// MoveHeuristic as well
+func (hp HeavyPlayout) ScoreMoves(g *game.Game, moves []board.Move) []float64 {
+	b := g.GetBoard()
+	color := g.NextTurn()
+	scores := make([]float64, len(moves))
+	for i, m := range moves {
+		score := hp.CaptureWeight * float64(len(m.GetJumpedPositions()))
+		if hp.PromotionWeight != 0 {
+			if piece := b.GetPiece(m.GetStart()); piece != nil && !piece.IsKing &&
+				b.Variant().IsPromotionRow(m.GetEnd().Row, color) {
+				score += hp.PromotionWeight
+			}
+		}
+		if hp.SafetyWeight != 0 {
+			after := g.Copy()
+			after.RunMove(m)
+			if replies := after.GetLegalMoves(); len(replies) > 0 && board.IsCapture(replies[0]) {
+				score -= hp.SafetyWeight
+			}
+		}
+		scores[i] = score
+	}
+	return scores
+}
+
+// PlayoutResult is the share of a win each color gets from a simulated game.
+// A draw gives each color half, as an even position does when a playout is
+// cut off and evaluated.
+type PlayoutResult struct {
+	Red, Blue float64
+}
+
+// ResultForState returns the result of a finished game
+func ResultForState(state game.GameState) PlayoutResult {
+	switch state {
+	case game.RedWin:
+		return PlayoutResult{Red: 1}
+	case game.BlueWin:
+		return PlayoutResult{Blue: 1}
+	}
+	return PlayoutResult{Red: 0.5, Blue: 0.5}
+}
+
+// evaluatedResult estimates the result of an unfinished game from the
+// evaluation of its board
+func evaluatedResult(g *game.Game, weights eval.Weights) PlayoutResult {
+	b := g.GetBoard()
+	p := eval.WinProbability(weights.Evaluate(&b, board.Red))
+	return PlayoutResult{Red: p, Blue: 1 - p}
+}
+
+// For returns the share of the result that goes to color
+func (pr PlayoutResult) For(color board.PieceColor) float64 {
+	if color == board.Red {
+		return pr.Red
+	}
+	return pr.Blue
+}
@@ -9,14 +9,38 @@ import (
 
 type RandomPlayer struct {
 	Color board.PieceColor
+	// Rand is the source of the player's choices. Nil uses the global source.
+	Rand *rand.Rand
 }
 
 func (r RandomPlayer) GetMove(g *game.Game) board.Move {
 	moves := g.GetLegalMoves()
 
-	randomIndex := rand.Intn(len(moves))
+	randomIndex := randIntn(r.Rand, len(moves))
 
 	randomMove := moves[randomIndex]
 
 	return randomMove
 }
+
+// NewRand creates a random source for a player from a seed
+func NewRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
+// PlayerSeeds derives distinct seeds for the two players of a game from the
+// seed of the game, so two players of the same kind do not make the same
+// random choices, nor share them with the players of another game
+func PlayerSeeds(seed int64) (red, blue int64) {
+	r := NewRand(seed)
+	return r.Int63(), r.Int63()
+}
+
+// randIntn returns a random number in [0,n) from r, or from the global
+// source if r is nil
+func randIntn(r *rand.Rand, n int) int {
+	if r == nil {
+		return rand.Intn(n)
+	}
+	return r.Intn(n)
+}
@@ -0,0 +1,97 @@
+package players
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/game"
+)
+
+// Searcher is a player that can report on the search behind its move
+type Searcher interface {
+	Search(ctx context.Context, g *game.Game, clock Clock) SearchInfo
+}
+
+// SearchInfo describes the search for a move
+type SearchInfo struct {
+	// Move is the move chosen
+	Move board.Move
+	// Iterations is the number of simulations of a Monte Carlo search, or
+	// the number of completed depths of a minimax search
+	Iterations int
+	// Nodes is the number of positions searched
+	Nodes int
+	// Depth is the deepest level of the tree reached, or the last completed
+	// depth of a minimax search
+	Depth    int
+	Duration time.Duration
+	// PV is the principal variation, the line the search expects, starting
+	// with Move
+	PV []board.Move
+	// Moves are the statistics of the moves of the position
+	Moves []MoveStats
+}
+
+// MoveStats is what a search found out about one of the moves of the position
+type MoveStats struct {
+	Move board.Move
+	// Visits is the number of simulations through the move, or the nodes
+	// searched below it by minimax
+	Visits int
+	// Value is the expected score of the move, from 0 for a loss to 1 for a
+	// win. For minimax the moves other than the best only have an upper
+	// bound.
+	Value float64
+}
+
+// NodesPerSecond returns the speed of the search
+func (si SearchInfo) NodesPerSecond() float64 {
+	if si.Duration <= 0 {
+		return 0
+	}
+	return float64(si.Nodes) / si.Duration.Seconds()
+}
+
+// Print writes the summary of the search and a table of the moves, the most
+// searched first
+func (si SearchInfo) Print(w io.Writer) {
+	fmt.Fprintf(w, "Move: %s Iterations: %d Nodes: %d Depth: %d Time: %s (%.0f nodes/s)\n",
+		board.MoveNotation(si.Move), si.Iterations, si.Nodes, si.Depth,
+		si.Duration.Round(time.Millisecond), si.NodesPerSecond())
+	pv := make([]string, len(si.PV))
+	for i, m := range si.PV {
+		pv[i] = board.MoveNotation(m)
+	}
+	fmt.Fprintf(w, "PV: %s\n", strings.Join(pv, " "))
+
+	moves := append([]MoveStats(nil), si.Moves...)
+	sort.SliceStable(moves, func(i, j int) bool {
+		return moves[i].Visits > moves[j].Visits
+	})
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Move\tVisits\tShare\tValue\t")
+	total := 0
+	for _, ms := range moves {
+		total += ms.Visits
+	}
+	for _, ms := range moves {
+		share := 0.0
+		if total > 0 {
+			share = float64(ms.Visits) / float64(total)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%.1f%%\t%.3f\t\n", board.MoveNotation(ms.Move), ms.Visits, 100*share, ms.Value)
+	}
+	tw.Flush()
+}
+
+// onlyMove is the SearchInfo of a position with a single legal move, which
+// needs no search
+func onlyMove(m board.Move) SearchInfo {
+	return SearchInfo{Move: m, PV: []board.Move{m}, Moves: []MoveStats{{Move: m}}}
+}
@@ -0,0 +1,90 @@
+package players
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/game"
+)
+
+func TestSearchInfo(t *testing.T) {
+	g := game.NewGame()
+	mcst := MCSTPlayer{
+		Color:              board.Red,
+		SelectionAlgorithm: MostVisits,
+		SearchBudget:       SearchBudget{Iterations: 1000},
+		Workers:            2,
+		Rand:               NewRand(1),
+	}
+	info := mcst.Search(context.Background(), g, Clock{})
+	if info.Iterations != 1000 || info.Nodes < 1000 || info.Depth == 0 {
+		t.Errorf("expected 1000 iterations with their nodes and depth, got %+v", info)
+	}
+	visits := 0
+	for _, ms := range info.Moves {
+		visits += ms.Visits
+	}
+	// Each worker's first iteration only visits its root
+	if visits != 998 {
+		t.Errorf("expected 998 visits to the root moves, got %d", visits)
+	}
+	if len(info.PV) < 2 || info.PV[0] != info.Move {
+		t.Errorf("expected a line starting with %s, got %v", board.MoveNotation(info.Move), info.PV)
+	}
+
+	minimax := MinimaxPlayer{Color: board.Red, MaxDepth: 4}
+	info = minimax.Search(context.Background(), g, Clock{})
+	if info.Depth != 4 || len(info.PV) != 4 || info.PV[0] != info.Move {
+		t.Errorf("expected a line of 4 moves starting with %s, got depth %d and %v",
+			board.MoveNotation(info.Move), info.Depth, info.PV)
+	}
+	checkLine(t, "minimax", g, info)
+
+	var sb strings.Builder
+	info.Print(&sb)
+	if !strings.Contains(sb.String(), "PV: "+board.MoveNotation(info.Move)) {
+		t.Errorf("expected the printed search to show the line, got\n%s", sb.String())
+	}
+
+	// With a transposition table nodes are shared between parents, and the
+	// line and the moves must still be those of the position
+	for seed := int64(1); seed <= 4; seed++ {
+		budget := SearchBudget{Iterations: 3000}
+		mcst := MCSTPlayer{
+			Color:              board.Red,
+			SelectionAlgorithm: MostVisits,
+			SearchBudget:       budget,
+			TableSize:          1 << 16,
+			Rand:               NewRand(seed),
+		}
+		checkLine(t, "mcst", g, mcst.Search(context.Background(), g, Clock{}))
+
+		rave := MCPlayerRave{Color: board.Red, SearchBudget: budget, TableSize: 1 << 16, Rand: NewRand(seed)}
+		checkLine(t, "rave", g, rave.Search(context.Background(), g, Clock{}))
+	}
+}
+
+// checkLine checks that the moves of the search are legal and that its
+// principal variation can be played out from g
+func checkLine(t *testing.T, name string, g *game.Game, info SearchInfo) {
+	t.Helper()
+	legal := g.GetLegalMoves()
+	for _, ms := range info.Moves {
+		if _, err := board.FindMove(legal, board.MoveNotation(ms.Move)); err != nil {
+			t.Errorf("%s: root move %s is not legal: %v", name, board.MoveNotation(ms.Move), err)
+		}
+	}
+	if len(info.PV) == 0 || info.PV[0] != info.Move {
+		t.Errorf("%s: expected a line starting with %s, got %v", name, board.MoveNotation(info.Move), info.PV)
+	}
+	work := g.Copy()
+	for i, m := range info.PV {
+		if _, err := board.FindMove(work.GetLegalMoves(), board.MoveNotation(m)); err != nil {
+			t.Errorf("%s: expected the line to be playable, move %d %s is not: %v", name, i+1, board.MoveNotation(m), err)
+			return
+		}
+		work.RunMove(m)
+	}
+}
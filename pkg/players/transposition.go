@@ -0,0 +1,69 @@
+package players
+
+// TranspositionTable is a fixed size table of search entries keyed by
+// position hash. Each hash maps to a single slot, and storing an entry
+// replaces whatever was in that slot, so memory stays bounded no matter how
+// long the search runs.
+type TranspositionTable[V any] struct {
+	entries []ttEntry[V]
+	mask    uint64
+	hits    int
+	misses  int
+}
+
+type ttEntry[V any] struct {
+	hash  uint64
+	used  bool
+	value V
+}
+
+// NewTranspositionTable creates a table with room for size entries. The
+// size is rounded down to a power of two.
+func NewTranspositionTable[V any](size int) *TranspositionTable[V] {
+	slots := 1
+	for slots*2 <= size {
+		slots *= 2
+	}
+	return &TranspositionTable[V]{
+		entries: make([]ttEntry[V], slots),
+		mask:    uint64(slots - 1),
+	}
+}
+
+// Get returns the entry stored for the hash
+func (tt *TranspositionTable[V]) Get(hash uint64) (V, bool) {
+	e := &tt.entries[hash&tt.mask]
+	if e.used && e.hash == hash {
+		tt.hits++
+		return e.value, true
+	}
+	tt.misses++
+	var zero V
+	return zero, false
+}
+
+// Put stores the entry for the hash, replacing the previous occupant of the slot
+func (tt *TranspositionTable[V]) Put(hash uint64, value V) {
+	tt.entries[hash&tt.mask] = ttEntry[V]{
+		hash:  hash,
+		used:  true,
+		value: value,
+	}
+}
+
+// Clear removes all entries
+func (tt *TranspositionTable[V]) Clear() {
+	clear(tt.entries)
+	tt.hits = 0
+	tt.misses = 0
+}
+
+// Size returns the number of slots in the table
+func (tt *TranspositionTable[V]) Size() int {
+	return len(tt.entries)
+}
+
+// Stats returns the number of successful and failed lookups
+func (tt *TranspositionTable[V]) Stats() (int, int) {
+	return tt.hits, tt.misses
+}
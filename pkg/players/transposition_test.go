@@ -0,0 +1,31 @@
+package players
+
+import "testing"
+
+func TestTranspositionTable(t *testing.T) {
+	tt := NewTranspositionTable[int](100)
+	if tt.Size() != 64 {
+		t.Errorf("Expected 64 slots, got %d", tt.Size())
+	}
+
+	tt.Put(5, 1)
+	if v, ok := tt.Get(5); !ok || v != 1 {
+		t.Errorf("Stored entry not found")
+	}
+	if _, ok := tt.Get(5 + 64); ok {
+		t.Errorf("Found entry for a different hash in the same slot")
+	}
+
+	tt.Put(5+64, 2)
+	if _, ok := tt.Get(5); ok {
+		t.Errorf("Entry not replaced")
+	}
+	if v, ok := tt.Get(5 + 64); !ok || v != 2 {
+		t.Errorf("Replacing entry not found")
+	}
+
+	tt.Clear()
+	if _, ok := tt.Get(5 + 64); ok {
+		t.Errorf("Entry found after Clear")
+	}
+}
@@ -0,0 +1,144 @@
+package players
+
+import (
+	"math"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/eval"
+	"github.com/ytaragin/checkers/pkg/game"
+)
+
+// TreePolicy decides which child the search descends into. The child with
+// the highest score is chosen.
+type TreePolicy interface {
+	// Expand is called once a node has its children, one for each of the
+	// moves, so that a policy that uses knowledge about the moves can store
+	// it on the node's edges. The children can be shared with other parents
+	// through a transposition table, so it is not stored in them.
+	Expand(node *MCSTNode, moves []board.Move)
+	// Score rates the i-th child of node, with the exploration constant of
+	// the search
+	Score(node *MCSTNode, i int, exploration float64) float64
+}
+
+// MoveHeuristic scores the moves of a position, higher for better moves, for
+// the tree policies that use knowledge
+type MoveHeuristic interface {
+	ScoreMoves(g *game.Game, moves []board.Move) []float64
+}
+
+// UCB1Policy is the mean result plus the UCB1 exploration term
+type UCB1Policy struct{}
+
+func (UCB1Policy) Expand(node *MCSTNode, moves []board.Move) {}
+
+func (UCB1Policy) Score(node *MCSTNode, i int, exploration float64) float64 {
+	return ucb(node.Children[i], node.VisitCount, exploration)
+}
+
+// UCB1TunedPolicy bounds the exploration term by the variance of the node's
+// results, so that moves whose results hardly vary are explored less. The
+// default exploration constant of sqrt(2) gives the formula of Auer et al.
+type UCB1TunedPolicy struct{}
+
+func (UCB1TunedPolicy) Expand(node *MCSTNode, moves []board.Move) {}
+
+func (UCB1TunedPolicy) Score(node *MCSTNode, i int, exploration float64) float64 {
+	child := node.Children[i]
+	if child.VisitCount == 0 {
+		return math.Inf(1)
+	}
+	n := float64(child.VisitCount)
+	logN := math.Log(float64(node.VisitCount))
+	mean := child.WinCount / n
+	variance := child.WinSquares/n - mean*mean + math.Sqrt(2*logN/n)
+	return mean + exploration/math.Sqrt2*math.Sqrt(logN/n*min(0.25, variance))
+}
+
+// PUCTPolicy explores the moves in proportion to their prior probabilities,
+// as in AlphaZero. The priors are a softmax of the Priors heuristic's scores.
+type PUCTPolicy struct {
+	Priors MoveHeuristic
+}
+
+func (p PUCTPolicy) Expand(node *MCSTNode, moves []board.Move) {
+	scores := scoreMoves(node.State, moves, p.Priors)
+	total := 0.0
+	for i, s := range scores {
+		scores[i] = math.Exp(s)
+		total += scores[i]
+	}
+	for i := range scores {
+		scores[i] /= total
+	}
+	node.ChildPriors = scores
+}
+
+func (PUCTPolicy) Score(node *MCSTNode, i int, exploration float64) float64 {
+	child := node.Children[i]
+	q := 0.0
+	if child.VisitCount > 0 {
+		q = child.WinCount / float64(child.VisitCount)
+	}
+	return q + exploration*edgeValue(node.ChildPriors, i)*math.Sqrt(float64(node.VisitCount))/float64(1+child.VisitCount)
+}
+
+// ProgressiveBiasPolicy adds the Heuristic's score of a move to UCB1, scaled
+// by Weight and fading as the move gets visits
+type ProgressiveBiasPolicy struct {
+	Heuristic MoveHeuristic
+	Weight    float64
+}
+
+func (p ProgressiveBiasPolicy) Expand(node *MCSTNode, moves []board.Move) {
+	node.ChildHeuristics = scoreMoves(node.State, moves, p.Heuristic)
+}
+
+func (p ProgressiveBiasPolicy) Score(node *MCSTNode, i int, exploration float64) float64 {
+	child := node.Children[i]
+	return ucb(child, node.VisitCount, exploration) + p.Weight*edgeValue(node.ChildHeuristics, i)/float64(1+child.VisitCount)
+}
+
+// EvalHeuristic scores a move by the evaluation of the position it leads to
+type EvalHeuristic struct {
+	Weights eval.Weights
+}
+
+func (h EvalHeuristic) ScoreMoves(g *game.Game, moves []board.Move) []float64 {
+	color := g.NextTurn()
+	scores := make([]float64, len(moves))
+	for i, m := range moves {
+		after := g.Copy()
+		after.RunMove(m)
+		b := after.GetBoard()
+		scores[i] = h.Weights.Evaluate(&b, color)
+	}
+	return scores
+}
+
+// edgeValue returns the value a policy stored for the i-th edge of a node, or
+// zero if the node was expanded without it
+func edgeValue(values []float64, i int) float64 {
+	if i >= len(values) {
+		return 0
+	}
+	return values[i]
+}
+
+// scoreMoves returns the heuristic's scores for the moves. Without a
+// heuristic every move scores zero.
+func scoreMoves(g *game.Game, moves []board.Move, h MoveHeuristic) []float64 {
+	if h == nil {
+		return make([]float64, len(moves))
+	}
+	return h.ScoreMoves(g, moves)
+}
+
+var (
+	UCB1      TreePolicy = UCB1Policy{}
+	UCB1Tuned TreePolicy = UCB1TunedPolicy{}
+	// PUCT takes its priors from the rules of the heavy playout
+	PUCT TreePolicy = PUCTPolicy{Priors: DefaultHeavyPlayout}
+	// ProgressiveBias takes its bias from the rules of the heavy playout
+	ProgressiveBias TreePolicy = ProgressiveBiasPolicy{Heuristic: DefaultHeavyPlayout, Weight: 1}
+)
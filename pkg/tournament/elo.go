@@ -0,0 +1,84 @@
+package tournament
+
+import "math"
+
+// eloScale converts between Elo points and the natural log of the odds
+var eloScale = 400 / math.Ln10
+
+// Rating is the Elo estimate of an entrant with its 95% confidence margin
+type Rating struct {
+	Elo    float64
+	Margin float64
+}
+
+// expectedScore returns the expected score of a player rated diff points
+// above the opponent
+func expectedScore(diff float64) float64 {
+	return 1 / (1 + math.Pow(10, -diff/400))
+}
+
+// Ratings estimates Elo ratings from the results by maximum likelihood. Every
+// pair that played gets one extra virtual draw so that an entrant who won or
+// lost every game still has a finite rating. The ratings average to zero.
+func (r *Results) Ratings() []Rating {
+	n := len(r.Names)
+	elo := make([]float64, n)
+
+	for iter := 0; iter < 100; iter++ {
+		maxStep := 0.0
+		for i := 0; i < n; i++ {
+			// Newton step on the log likelihood for entrant i
+			var gradient, curvature float64
+			for j := 0; j < n; j++ {
+				games, score := r.virtualRecord(i, j)
+				if games == 0 {
+					continue
+				}
+				e := expectedScore(elo[i] - elo[j])
+				gradient += score - games*e
+				curvature += games * e * (1 - e)
+			}
+			if curvature == 0 {
+				continue
+			}
+			step := eloScale * gradient / curvature
+			elo[i] += step
+			maxStep = math.Max(maxStep, math.Abs(step))
+		}
+		if maxStep < 1e-6 {
+			break
+		}
+	}
+
+	mean := 0.0
+	for _, e := range elo {
+		mean += e
+	}
+	mean /= float64(n)
+
+	ratings := make([]Rating, n)
+	for i := range ratings {
+		var information float64
+		for j := 0; j < n; j++ {
+			games, _ := r.virtualRecord(i, j)
+			e := expectedScore(elo[i] - elo[j])
+			information += games * e * (1 - e)
+		}
+		margin := math.Inf(1)
+		if information > 0 {
+			margin = 1.96 * eloScale / math.Sqrt(information)
+		}
+		ratings[i] = Rating{Elo: elo[i] - mean, Margin: margin}
+	}
+	return ratings
+}
+
+// virtualRecord returns the games and score of i against j including the
+// virtual draw
+func (r *Results) virtualRecord(i, j int) (games, score float64) {
+	rec := r.Records[i][j]
+	if i == j || rec.Games() == 0 {
+		return 0, 0
+	}
+	return float64(rec.Games()) + 1, rec.Score() + 0.5
+}
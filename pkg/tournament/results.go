@@ -0,0 +1,123 @@
+package tournament
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/ytaragin/checkers/pkg/game"
+)
+
+// Record counts the games one entrant played against another
+type Record struct {
+	Wins, Draws, Losses int
+}
+
+// Games returns the number of games in the record
+func (r Record) Games() int {
+	return r.Wins + r.Draws + r.Losses
+}
+
+// Score returns the points scored, one for a win and a half for a draw
+func (r Record) Score() float64 {
+	return float64(r.Wins) + 0.5*float64(r.Draws)
+}
+
+// Results holds the outcome of every pairing in a tournament
+type Results struct {
+	Names []string
+	// Records[i][j] is the record of entrant i against entrant j
+	Records [][]Record
+}
+
+// NewResults creates empty results for the named entrants
+func NewResults(names []string) *Results {
+	records := make([][]Record, len(names))
+	for i := range records {
+		records[i] = make([]Record, len(names))
+	}
+	return &Results{Names: names, Records: records}
+}
+
+// Add records the result of a game between the entrants playing red and blue
+func (r *Results) Add(red, blue int, state game.GameState) {
+	switch state {
+	case game.RedWin:
+		r.Records[red][blue].Wins++
+		r.Records[blue][red].Losses++
+	case game.BlueWin:
+		r.Records[red][blue].Losses++
+		r.Records[blue][red].Wins++
+	case game.Draw:
+		r.Records[red][blue].Draws++
+		r.Records[blue][red].Draws++
+	}
+}
+
+// Total returns the combined record of the entrant against everyone
+func (r *Results) Total(i int) Record {
+	var total Record
+	for _, rec := range r.Records[i] {
+		total.Wins += rec.Wins
+		total.Draws += rec.Draws
+		total.Losses += rec.Losses
+	}
+	return total
+}
+
+// PrintCrosstable writes the score of every entrant against every other one
+func (r *Results) PrintCrosstable(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprint(tw, "#\tName")
+	for j := range r.Names {
+		fmt.Fprintf(tw, "\t%d", j+1)
+	}
+	fmt.Fprintln(tw, "\tScore\tGames\t")
+
+	for i, name := range r.Names {
+		fmt.Fprintf(tw, "%d\t%s", i+1, name)
+		for j := range r.Names {
+			rec := r.Records[i][j]
+			switch {
+			case i == j:
+				fmt.Fprint(tw, "\t-")
+			case rec.Games() == 0:
+				fmt.Fprint(tw, "\t.")
+			default:
+				fmt.Fprintf(tw, "\t%g/%d", rec.Score(), rec.Games())
+			}
+		}
+		total := r.Total(i)
+		fmt.Fprintf(tw, "\t%g\t%d\t\n", total.Score(), total.Games())
+	}
+	tw.Flush()
+}
+
+// PrintRatings writes the entrants ordered by rating with their records
+func (r *Results) PrintRatings(w io.Writer) {
+	ratings := r.Ratings()
+	order := make([]int, len(ratings))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return ratings[order[a]].Elo > ratings[order[b]].Elo
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Rank\tName\tElo\t+/-\tGames\tW\tD\tL\tScore\t")
+	for rank, i := range order {
+		total := r.Total(i)
+		score := math.NaN()
+		if total.Games() > 0 {
+			score = 100 * total.Score() / float64(total.Games())
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%.0f\t%.0f\t%d\t%d\t%d\t%d\t%.1f%%\t\n",
+			rank+1, r.Names[i], ratings[i].Elo, ratings[i].Margin,
+			total.Games(), total.Wins, total.Draws, total.Losses, score)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,143 @@
+// Package tournament plays matches between any number of players and rates
+// them with Elo
+package tournament
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/game"
+	"github.com/ytaragin/checkers/pkg/players"
+)
+
+// Format decides which entrants play each other
+type Format int
+
+const (
+	// RoundRobin pairs every entrant with every other entrant
+	RoundRobin Format = iota
+	// Gauntlet pairs the first entrant with each of the others
+	Gauntlet
+)
+
+func (f Format) String() string {
+	if f == Gauntlet {
+		return "Gauntlet"
+	}
+	return "RoundRobin"
+}
+
+// Entrant is a named player taking part in a tournament
+type Entrant struct {
+	Name string
+	New  players.PlayerFactory
+}
+
+// Tournament describes the entrants and how they are paired
+type Tournament struct {
+	Entrants []Entrant
+	Format   Format
+	// GamesPerPairing is the number of games each pair plays. The colors
+	// alternate from game to game, so an even number is fairest.
+	GamesPerPairing int
+	// Variant is the rules the games are played by. Nil means American.
+	Variant *board.Variant
+	// Seed is the seed of the first game, the games after it count up from it
+	Seed int64
+	// RandomOpening is the number of random moves played at the start of
+	// every game
+	RandomOpening int
+	// TimeControl is the time each player gets for a game. The zero value
+	// means the games are not timed.
+	TimeControl players.TimeControl
+	// Progress gets a line per finished game when it is set
+	Progress io.Writer
+}
+
+// Pairings returns the pairs of entrant indexes that play each other
+func (t *Tournament) Pairings() [][2]int {
+	var pairs [][2]int
+	switch t.Format {
+	case Gauntlet:
+		for j := 1; j < len(t.Entrants); j++ {
+			pairs = append(pairs, [2]int{0, j})
+		}
+	default:
+		for i := 0; i < len(t.Entrants); i++ {
+			for j := i + 1; j < len(t.Entrants); j++ {
+				pairs = append(pairs, [2]int{i, j})
+			}
+		}
+	}
+	return pairs
+}
+
+// Run plays all the games of the tournament
+func (t *Tournament) Run() *Results {
+	names := make([]string, len(t.Entrants))
+	for i, e := range t.Entrants {
+		names[i] = e.Name
+	}
+	results := NewResults(names)
+
+	variant := t.Variant
+	if variant == nil {
+		variant = board.American
+	}
+	gamesPerPairing := t.GamesPerPairing
+	if gamesPerPairing <= 0 {
+		gamesPerPairing = 2
+	}
+
+	seed := t.Seed
+	for _, pair := range t.Pairings() {
+		for n := 0; n < gamesPerPairing; n++ {
+			// The first entrant of the pair takes the first move in even games
+			first, second := pair[0], pair[1]
+			if n%2 == 1 {
+				first, second = second, first
+			}
+			red, blue := first, second
+			if variant.FirstTurn == board.Blue {
+				red, blue = second, first
+			}
+
+			redSeed, blueSeed := players.PlayerSeeds(seed)
+			g := game.NewVariantGame(variant)
+			runner := players.RunGame(g,
+				t.Entrants[red].New(board.Red, redSeed),
+				t.Entrants[blue].New(board.Blue, blueSeed))
+			runner.SetRand(players.NewRand(seed))
+			runner.PlayRandomOpening(t.RandomOpening)
+			if t.TimeControl.Base > 0 {
+				runner.SetTimeControl(t.TimeControl)
+			}
+			seed++
+			state := runner.Play()
+			results.Add(red, blue, state)
+
+			if t.Progress != nil {
+				onTime := ""
+				if runner.TimedOut() {
+					onTime = " on time"
+				}
+				fmt.Fprintf(t.Progress, "%s (Red) - %s (Blue): %s%s in %d moves\n",
+					names[red], names[blue], stateName(state), onTime, g.MoveCount())
+			}
+		}
+	}
+	return results
+}
+
+func stateName(state game.GameState) string {
+	switch state {
+	case game.RedWin:
+		return "Red wins"
+	case game.BlueWin:
+		return "Blue wins"
+	case game.Draw:
+		return "Draw"
+	}
+	return "Unfinished"
+}
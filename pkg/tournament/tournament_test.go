@@ -0,0 +1,88 @@
+package tournament
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ytaragin/checkers/pkg/board"
+	"github.com/ytaragin/checkers/pkg/game"
+	"github.com/ytaragin/checkers/pkg/players"
+)
+
+func TestRatingsFromScore(t *testing.T) {
+	r := NewResults([]string{"strong", "weak"})
+	for i := 0; i < 300; i++ {
+		r.Add(0, 1, game.RedWin)
+	}
+	for i := 0; i < 100; i++ {
+		r.Add(1, 0, game.RedWin)
+	}
+
+	ratings := r.Ratings()
+	// A 75% score is worth about 191 Elo
+	diff := ratings[0].Elo - ratings[1].Elo
+	if math.Abs(diff-191) > 2 {
+		t.Errorf("expected a difference of about 191, got %.1f", diff)
+	}
+	if math.Abs(ratings[0].Elo+ratings[1].Elo) > 1e-6 {
+		t.Errorf("expected ratings to average 0, got %.1f and %.1f", ratings[0].Elo, ratings[1].Elo)
+	}
+	if ratings[0].Margin <= 0 || math.IsInf(ratings[0].Margin, 0) {
+		t.Errorf("expected a finite margin, got %f", ratings[0].Margin)
+	}
+}
+
+func TestRatingsPerfectScore(t *testing.T) {
+	r := NewResults([]string{"a", "b"})
+	r.Add(0, 1, game.RedWin)
+	r.Add(1, 0, game.BlueWin)
+
+	ratings := r.Ratings()
+	if math.IsInf(ratings[0].Elo, 0) || math.IsNaN(ratings[0].Elo) || ratings[0].Elo <= ratings[1].Elo {
+		t.Errorf("expected a finite rating ahead of the loser, got %v", ratings)
+	}
+}
+
+func TestRoundRobin(t *testing.T) {
+	random := func(color board.PieceColor, seed int64) players.Player {
+		return players.RandomPlayer{Color: color}
+	}
+	tour := Tournament{
+		Entrants: []Entrant{
+			{Name: "a", New: random},
+			{Name: "b", New: random},
+			{Name: "c", New: random},
+		},
+		GamesPerPairing: 2,
+	}
+
+	results := tour.Run()
+	for i := range tour.Entrants {
+		if games := results.Total(i).Games(); games != 4 {
+			t.Errorf("expected entrant %d to play 4 games, got %d", i, games)
+		}
+	}
+
+	tour.Format = Gauntlet
+	if pairs := tour.Pairings(); len(pairs) != 2 {
+		t.Errorf("expected 2 gauntlet pairings, got %d", len(pairs))
+	}
+}
+
+func TestEntrantSeeds(t *testing.T) {
+	seen := map[int64]int{}
+	random := func(color board.PieceColor, seed int64) players.Player {
+		seen[seed]++
+		return players.RandomPlayer{Color: color, Rand: players.NewRand(seed)}
+	}
+	tour := Tournament{
+		Entrants:        []Entrant{{Name: "a", New: random}, {Name: "b", New: random}},
+		GamesPerPairing: 4,
+		Seed:            3,
+	}
+
+	tour.Run()
+	if len(seen) != 8 {
+		t.Errorf("expected a seed for each player of each game, got %v", seen)
+	}
+}